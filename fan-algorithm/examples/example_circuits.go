@@ -2,6 +2,8 @@
 package examples
 
 import (
+	"strings"
+
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
 )
 
@@ -112,6 +114,8 @@ func CreateSimpleCircuit() *circuit.Circuit {
 	c.AddGate(g2)
 
 	// Set up connections
+	in1.AddFanout(n1)
+	in2.AddFanout(n1)
 	n1.FanIn = g1
 	n1.AddFanout(out1)
 	out1.FanIn = g2
@@ -155,10 +159,17 @@ func CreateFanTestCircuit() *circuit.Circuit {
 	g4 := circuit.NewGate("g4", circuit.OR, []*circuit.Signal{n3}, out1, c)
 	g5 := circuit.NewGate("g5", circuit.AND, []*circuit.Signal{n2}, out2, c)
 
-	c.Gates = append(c.Gates, g1, g2, g3, g4, g5)
-	c.Signals = append(c.Signals, in1, in2, in3, n1, n2, n3, out1, out2)
+	c.AddGate(g1)
+	c.AddGate(g2)
+	c.AddGate(g3)
+	c.AddGate(g4)
+	c.AddGate(g5)
 
 	// Create mandatory paths through n2
+	in1.AddFanout(n1)
+	in2.AddFanout(n1)
+	n1.AddFanout(n2)
+	in3.AddFanout(n2)
 	n2.AddFanout(n3)
 	n2.AddFanout(out2)
 	n3.AddFanout(out1)
@@ -166,3 +177,74 @@ func CreateFanTestCircuit() *circuit.Circuit {
 	c.IdentifyBoundAndHeadLines()
 	return c
 }
+
+// CreateReconvergentCircuit creates a circuit where fanout stem a reconverges at z
+func CreateReconvergentCircuit() *circuit.Circuit {
+	c := circuit.NewCircuit()
+
+	a := circuit.NewSignal("a")
+	b := circuit.NewSignal("b")
+	cIn := circuit.NewSignal("c")
+	d := circuit.NewSignal("d")
+	x := circuit.NewSignal("x")
+	y := circuit.NewSignal("y")
+	z := circuit.NewSignal("z")
+	out := circuit.NewSignal("out")
+
+	c.AddPrimaryInput(a)
+	c.AddPrimaryInput(b)
+	c.AddPrimaryInput(cIn)
+	c.AddPrimaryInput(d)
+	c.AddPrimaryOutput(out)
+
+	// z = OR(AND(a, b), AND(a, c)) requires a=1, which direct implication can't see
+	c.AddGate(circuit.NewGate("g1", circuit.AND, []*circuit.Signal{a, b}, x, c))
+	c.AddGate(circuit.NewGate("g2", circuit.AND, []*circuit.Signal{a, cIn}, y, c))
+	c.AddGate(circuit.NewGate("g3", circuit.OR, []*circuit.Signal{x, y}, z, c))
+	c.AddGate(circuit.NewGate("g4", circuit.AND, []*circuit.Signal{z, d}, out, c))
+
+	a.AddFanout(x)
+	a.AddFanout(y)
+	b.AddFanout(x)
+	cIn.AddFanout(y)
+	x.AddFanout(z)
+	y.AddFanout(z)
+	z.AddFanout(out)
+	d.AddFanout(out)
+
+	c.IdentifyBoundAndHeadLines()
+	return c
+}
+
+// S27Bench is the ISCAS-89 s27 benchmark in .bench format
+const S27Bench = `# s27
+INPUT(G0)
+INPUT(G1)
+INPUT(G2)
+INPUT(G3)
+OUTPUT(G17)
+
+G5 = DFF(G10)
+G6 = DFF(G11)
+G7 = DFF(G13)
+
+G14 = NOT(G0)
+G17 = NOT(G11)
+G8 = AND(G14, G6)
+G15 = OR(G12, G8)
+G16 = OR(G3, G8)
+G9 = NAND(G16, G15)
+G10 = NOR(G14, G11)
+G11 = NOR(G5, G9)
+G12 = NOR(G1, G7)
+G13 = NOR(G2, G12)
+`
+
+// CreateS27Circuit creates the ISCAS-89 s27 benchmark circuit with three flip-flops
+func CreateS27Circuit() *circuit.Circuit {
+	c, err := circuit.ReadBench(strings.NewReader(S27Bench))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -34,24 +34,14 @@ func MultipleBacktrace(initialObjectives []*types.BacktraceObjective, c *circuit
 		}
 	}
 
+	currentObjectives := initialObjectives
+
 	// Process unique sensitization if enabled
 	if config.UseUniqueSensitization && len(initialObjectives) > 0 {
 		result.Stats.Decisions++
-		if paths := findMandatoryPaths(initialObjectives[0].Signal, c); len(paths) > 0 {
-			for _, signal := range paths {
-				obj := &types.BacktraceObjective{
-					Signal:    signal,
-					Value:     circuit.ONE,
-					Priority:  10,
-					OneCount:  1,
-					ZeroCount: 0,
-				}
-				result.FinalObjectives = append(result.FinalObjectives, obj)
-			}
-		}
+		currentObjectives = append(currentObjectives,
+			uniqueSensitizationObjectives(initialObjectives[0].Signal, c)...)
 	}
-
-	currentObjectives := initialObjectives
 	processed := make(map[string]bool)
 
 	for len(currentObjectives) > 0 {
@@ -63,14 +53,16 @@ func MultipleBacktrace(initialObjectives []*types.BacktraceObjective, c *circuit
 			}
 			processed[obj.Signal.ID] = true
 
-			// Process through gates if available
-			if obj.Signal.FanIn != nil {
+			// Backtrace stops at head lines, their free regions are justified later
+			if obj.Signal.FanIn != nil && !obj.Signal.IsHead {
 				newObjs := backtraceGateWithCost(obj.Signal.FanIn, obj)
 				for _, newObj := range newObjs {
-					// Add to final objectives if head line
+					// Add to final objectives if head line or primary input
 					if newObj.Signal.IsHead {
 						result.HeadLines = append(result.HeadLines, newObj.Signal)
 						result.FinalObjectives = append(result.FinalObjectives, newObj)
+					} else if newObj.Signal.FanIn == nil {
+						result.FinalObjectives = append(result.FinalObjectives, newObj)
 					}
 					nextObjectives = append(nextObjectives, newObj)
 				}
@@ -98,6 +90,9 @@ func backtraceGateWithCost(gate *circuit.Gate, obj *types.BacktraceObjective) []
 		if obj.Value == circuit.ONE {
 			// AND=1 requires all inputs=1
 			for _, input := range gate.Inputs {
+				if input.Value != circuit.X {
+					continue
+				}
 				newObj := &types.BacktraceObjective{
 					Signal:    input,
 					Value:     circuit.ONE,
@@ -110,6 +105,9 @@ func backtraceGateWithCost(gate *circuit.Gate, obj *types.BacktraceObjective) []
 		} else {
 			// AND=0 requires any input=0
 			easiest := gate.GetEasiestControllingInput()
+			if easiest == nil {
+				break
+			}
 			results = append(results, &types.BacktraceObjective{
 				Signal:    easiest,
 				Value:     circuit.ZERO,
@@ -122,6 +120,9 @@ func backtraceGateWithCost(gate *circuit.Gate, obj *types.BacktraceObjective) []
 		if obj.Value == circuit.ZERO {
 			// OR=0 requires all inputs=0
 			for _, input := range gate.Inputs {
+				if input.Value != circuit.X {
+					continue
+				}
 				newObj := &types.BacktraceObjective{
 					Signal:    input,
 					Value:     circuit.ZERO,
@@ -134,6 +135,9 @@ func backtraceGateWithCost(gate *circuit.Gate, obj *types.BacktraceObjective) []
 		} else {
 			// OR=1 requires any input=1
 			easiest := gate.GetEasiestControllingInput()
+			if easiest == nil {
+				break
+			}
 			results = append(results, &types.BacktraceObjective{
 				Signal:    easiest,
 				Value:     circuit.ONE,
@@ -245,66 +249,20 @@ func oppositeValue(value circuit.SignalValue) circuit.SignalValue {
 	return circuit.ZERO
 }
 
-func findMandatoryPaths(signal *circuit.Signal, c *circuit.Circuit) []*circuit.Signal {
-	if signal.FanIn == nil {
-		return nil
-	}
-
-	// Find all paths to outputs
-	paths := findAllPaths(signal, c)
-	if len(paths) == 0 {
+// uniqueSensitizationObjectives creates objectives for the unassigned off-path
+// inputs of every dominator of the signal, if its gate is on the D-frontier.
+// Other objectives justify values, their gates need no sensitization.
+func uniqueSensitizationObjectives(signal *circuit.Signal, c *circuit.Circuit) []*types.BacktraceObjective {
+	if signal.FanIn == nil || !isDFrontierGate(signal.FanIn) {
 		return nil
 	}
 
-	// Find signals that appear in all paths
-	mandatory := findCommonSignals(paths)
-	return mandatory
-}
-
-func findAllPaths(signal *circuit.Signal, c *circuit.Circuit) [][]*circuit.Signal {
-	// Use PathFinder from sensitization package
 	pf := sensitization.NewPathFinder(c)
-	dFrontier := []*circuit.Gate{signal.FanIn}
-	paths := pf.FindUniqueSensitizationPaths(dFrontier)
-
-	// Convert Path objects to signal paths
-	signalPaths := make([][]*circuit.Signal, 0)
-	for _, path := range paths {
-		signalPath := make([]*circuit.Signal, 0)
-		for _, gate := range path.Gates {
-			signalPath = append(signalPath, gate.Output)
-		}
-		signalPaths = append(signalPaths, signalPath)
-	}
-
-	return signalPaths
-}
-
-func findCommonSignals(paths [][]*circuit.Signal) []*circuit.Signal {
-	if len(paths) == 0 {
-		return nil
-	}
-
-	// Count occurrences of each signal
-	signalCount := make(map[*circuit.Signal]int)
-	for _, path := range paths {
-		// Use map to avoid counting duplicates within same path
-		seen := make(map[*circuit.Signal]bool)
-		for _, signal := range path {
-			if !seen[signal] {
-				signalCount[signal]++
-				seen[signal] = true
-			}
+	objectives := make([]*types.BacktraceObjective, 0)
+	for _, assignment := range pf.UniqueSensitization([]*circuit.Gate{signal.FanIn}, 0) {
+		if assignment.Signal.Value == circuit.X {
+			objectives = append(objectives, newObjective(assignment.Signal, assignment.Value, 10))
 		}
 	}
-
-	// Find signals that appear in all paths
-	common := make([]*circuit.Signal, 0)
-	for signal, count := range signalCount {
-		if count == len(paths) {
-			common = append(common, signal)
-		}
-	}
-
-	return common
+	return objectives
 }
@@ -0,0 +1,193 @@
+// conflict.go
+package algorithm
+
+import (
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/strategy"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// conflictAssignments collects the assignments a conflict may depend on: the
+// decisions and the values required by unique sensitization since the last backtrack
+func conflictAssignments(decisions []*strategy.DecisionNode, assigned []types.Assignment) []types.Assignment {
+	assumed := make([]types.Assignment, 0, len(decisions)+len(assigned))
+	for _, decision := range decisions {
+		assumed = append(assumed, types.Assignment{
+			Signal: decision.Signal,
+			Value:  decision.Value,
+			Reason: types.DECISION,
+			Level:  decision.Level,
+		})
+	}
+	for _, assignment := range assigned {
+		if assignment.Reason == types.UNIQUE_SENSITIZATION {
+			assumed = append(assumed, assignment)
+		}
+	}
+	return assumed
+}
+
+// analyzeConflict explains an inconsistency found under the given assignments.
+// The assignments are replayed and every one the conflict does not depend on
+// is dropped, what remains can't be extended to a test. The clause is global if
+// the remaining assignments are also inconsistent in the fault-free circuit.
+// Also returns the decision levels of the remaining assignments, the search
+// can jump back to the deepest of them directly. Returns nil for both if
+// replaying the assignments does not reproduce the conflict.
+func analyzeConflict(c *circuit.Circuit, fault *stuckAtFault, assumed []types.Assignment,
+	learned *types.LearnedImplicationTable) (*types.ConflictClause, map[int]bool) {
+
+	if len(assumed) == 0 || !isInconsistent(c, fault, assumed, learned) {
+		return nil, nil
+	}
+
+	// Drop assignments from the most recent one, later ones are the most
+	// likely to be consequences of the earlier ones
+	for i := len(assumed) - 1; i >= 0 && len(assumed) > 1; i-- {
+		reduced := make([]types.Assignment, 0, len(assumed)-1)
+		reduced = append(reduced, assumed[:i]...)
+		reduced = append(reduced, assumed[i+1:]...)
+		if isInconsistent(c, fault, reduced, learned) {
+			assumed = reduced
+		}
+	}
+
+	levels := make(map[int]bool)
+	clause := &types.ConflictClause{Literals: make([]types.ConflictLiteral, len(assumed))}
+	for i, assignment := range assumed {
+		clause.Literals[i] = types.ConflictLiteral{Signal: assignment.Signal, Value: assignment.Value}
+		addLevels(levels, assignment)
+	}
+	clause.Global = isInconsistent(c, fault.faultFree(), assumed, learned)
+	return clause, levels
+}
+
+// isInconsistent checks if implication fails once the assignments are made
+func isInconsistent(c *circuit.Circuit, fault *stuckAtFault, assumed []types.Assignment,
+	learned *types.LearnedImplicationTable) bool {
+
+	// Replay on a cleared circuit and undo it all through the trail
+	trail := c.Trail()
+	position := trail.Len()
+	defer trail.UndoTo(position)
+
+	for _, signal := range c.Signals {
+		signal.Assign(circuit.X)
+	}
+	fault.activate()
+	for _, assignment := range assumed {
+		assignment.Signal.Assign(assignment.Value)
+	}
+	return !performImplication(c, fault, learned, nil)
+}
+
+// clauseLevels returns the decision levels a violated clause depends on,
+// or nil if a literal holds for a reason not among the given assignments
+func clauseLevels(clause *types.ConflictClause, assumed []types.Assignment) map[int]bool {
+	levels := make(map[int]bool)
+	for _, literal := range clause.Literals {
+		found := false
+		for _, assignment := range assumed {
+			if assignment.Signal == literal.Signal && assignment.Value == literal.Value {
+				addLevels(levels, assignment)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return levels
+}
+
+// addLevels marks the decision levels an assumed assignment rests on. A
+// value required by unique sensitization follows from the D-frontier it was
+// derived from, so it rests on every decision up to its level.
+func addLevels(levels map[int]bool, assignment types.Assignment) {
+	if assignment.Reason != types.UNIQUE_SENSITIZATION {
+		levels[assignment.Level] = true
+		return
+	}
+	for level := 1; level <= assignment.Level; level++ {
+		levels[level] = true
+	}
+}
+
+// clauseSignals returns the lines of a clause
+func clauseSignals(clause *types.ConflictClause) []*circuit.Signal {
+	signals := make([]*circuit.Signal, len(clause.Literals))
+	for i, literal := range clause.Literals {
+		signals[i] = literal.Signal
+	}
+	return signals
+}
+
+// storeConflictClause adds a clause to the shared store if it holds for every
+// fault and sharing is enabled, otherwise to the store of the current fault
+func storeConflictClause(clause *types.ConflictClause, local *types.ConflictClauseDB,
+	config *types.TestGenerationConfig, stats *types.TestGenerationStats) {
+
+	db := local
+	if clause.Global && config.ConflictClauses != nil {
+		db = config.ConflictClauses
+	}
+	if db.Add(clause) {
+		stats.LearnedClauses++
+	}
+}
+
+// applyConflictClauses checks the current values against the learned clauses.
+// A clause with all literals holding is violated. A clause with a single
+// unassigned literal left forces the opposite value on it, unless the fault
+// effect can reach the line. Returns whether any line changed and the violated
+// clause, if any.
+func applyConflictClauses(fault *stuckAtFault, dbs []*types.ConflictClauseDB,
+	stats *types.TestGenerationStats) (bool, *types.ConflictClause) {
+
+	changed := false
+	for _, db := range dbs {
+		if db == nil {
+			continue
+		}
+		for _, clause := range db.Clauses() {
+			var open *types.ConflictLiteral
+			holding := 0
+			for i := range clause.Literals {
+				literal := &clause.Literals[i]
+				value := literal.Signal.Value
+				if clause.Global {
+					value = goodValueOf(value)
+				}
+				switch value {
+				case literal.Value:
+					holding++
+				case circuit.X:
+					open = literal
+				}
+			}
+
+			switch {
+			case holding == len(clause.Literals):
+				stats.ClausePrunes++
+				return changed, clause
+			case holding == len(clause.Literals)-1 && open != nil && !fault.inFanoutCone(open.Signal):
+				open.Signal.Assign(getOppositeValue(open.Value))
+				changed = true
+			}
+		}
+	}
+	return changed, nil
+}
+
+// goodValueOf returns the fault-free part of a five-valued value
+func goodValueOf(value circuit.SignalValue) circuit.SignalValue {
+	switch value {
+	case circuit.D:
+		return circuit.ONE
+	case circuit.D_BAR:
+		return circuit.ZERO
+	default:
+		return value
+	}
+}
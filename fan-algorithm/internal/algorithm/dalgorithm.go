@@ -0,0 +1,272 @@
+// dalgorithm.go
+package algorithm
+
+import (
+	"time"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/sensitization"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// Cube holds one value per gate input followed by the gate output value
+type Cube []circuit.SignalValue
+
+// Output returns the output value of the cube
+func (cube Cube) Output() circuit.SignalValue {
+	return cube[len(cube)-1]
+}
+
+// SingularCover returns the singular cover of a gate, the cubes that justify its output
+func SingularCover(gate *circuit.Gate) []Cube {
+	n := len(gate.Inputs)
+	cubes := make([]Cube, 0)
+
+	switch gate.Type {
+	case circuit.NOT:
+		cubes = append(cubes, Cube{circuit.ZERO, circuit.ONE}, Cube{circuit.ONE, circuit.ZERO})
+	case circuit.AND, circuit.OR:
+		nonControlling := gate.GetNonControllingValue()
+		controlling := getOppositeValue(nonControlling)
+
+		// Controlling value on a single input, the rest left unspecified
+		for i := 0; i < n; i++ {
+			cube := newCube(n, circuit.X)
+			cube[i] = controlling
+			cube[n] = controlling
+			cubes = append(cubes, cube)
+		}
+		// Non-controlling value on every input
+		cube := newCube(n, nonControlling)
+		cube[n] = nonControlling
+		cubes = append(cubes, cube)
+	}
+	return cubes
+}
+
+// PropagationDCubes returns the primitive D-cubes of propagation of a gate,
+// one for each input and fault effect polarity
+func PropagationDCubes(gate *circuit.Gate) []Cube {
+	n := len(gate.Inputs)
+	cubes := make([]Cube, 0)
+
+	for _, effect := range []circuit.SignalValue{circuit.D, circuit.D_BAR} {
+		switch gate.Type {
+		case circuit.NOT:
+			cubes = append(cubes, Cube{effect, getOppositeValue(effect)})
+		case circuit.AND, circuit.OR:
+			for i := 0; i < n; i++ {
+				cube := newCube(n, gate.GetNonControllingValue())
+				cube[i] = effect
+				cube[n] = effect
+				cubes = append(cubes, cube)
+			}
+		}
+	}
+	return cubes
+}
+
+// reconvergentDCube returns the D-cube propagating the fault effects already on
+// several inputs of a gate, which no primitive D-cube of propagation intersects.
+// Returns nil if at most one input carries a fault effect or the effects cancel.
+func reconvergentDCube(gate *circuit.Gate) Cube {
+	n := len(gate.Inputs)
+	cube := newCube(n, gate.GetNonControllingValue())
+	effect := circuit.X
+	effects := 0
+	for i, input := range gate.Inputs {
+		if !input.IsFaulty() {
+			continue
+		}
+		if effect != circuit.X && input.Value != effect {
+			return nil // Both polarities yield the controlled value
+		}
+		effect = input.Value
+		cube[i] = effect
+		effects++
+	}
+	if effects < 2 {
+		return nil
+	}
+	cube[n] = effect
+	return cube
+}
+
+// PrimitiveDCubesOfFailure returns the cubes activating a stuck-at fault on the gate output.
+// They are the singular cubes producing the fault-free value, with the output replaced by the fault effect.
+func PrimitiveDCubesOfFailure(gate *circuit.Gate, stuckAt circuit.SignalValue) []Cube {
+	fault := &stuckAtFault{Site: gate.Output, StuckAt: stuckAt}
+	cubes := make([]Cube, 0)
+	for _, cube := range SingularCover(gate) {
+		if cube.Output() == fault.goodValue() {
+			failure := append(Cube{}, cube...)
+			failure[len(failure)-1] = fault.effect()
+			cubes = append(cubes, failure)
+		}
+	}
+	return cubes
+}
+
+func newCube(inputs int, value circuit.SignalValue) Cube {
+	cube := make(Cube, inputs+1)
+	for i := range cube {
+		cube[i] = value
+	}
+	cube[inputs] = circuit.X
+	return cube
+}
+
+// dAlgorithm holds the search state of a D-algorithm run
+type dAlgorithm struct {
+	circuit    *circuit.Circuit
+	fault      *stuckAtFault
+	config     *types.TestGenerationConfig
+	result     *types.TestResult
+	decisions  []types.Decision
+	pathFinder *sensitization.PathFinder
+	start      time.Time
+	err        types.TestGenerationError
+}
+
+// DAlgorithm runs the Roth D-algorithm with the default configuration
+func DAlgorithm(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *types.TestResult {
+	return DAlgorithmWithConfig(c, faultSite, faultValue, types.NewTestGenerationConfig())
+}
+
+// DAlgorithmWithConfig runs the D-algorithm with the given configuration.
+// Decisions are cube selections on internal lines, for the D-frontier from the
+// propagation D-cubes and for the J-frontier from the singular covers.
+func DAlgorithmWithConfig(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue,
+	config *types.TestGenerationConfig) *types.TestResult {
+
+	d := &dAlgorithm{
+		circuit:    c,
+		fault:      &stuckAtFault{Site: faultSite, StuckAt: faultValue},
+		config:     config,
+		result:     types.NewTestResult(),
+		decisions:  make([]types.Decision, 0),
+		pathFinder: sensitization.NewPathFinder(c),
+		start:      time.Now(),
+	}
+
+	resetCircuit(c)
+	d.fault.activate()
+
+	if d.search() {
+		d.result.Success = true
+		saveTestPattern(c, d.result)
+	} else if d.err != nil {
+		d.result.Error = d.err
+	} else {
+		d.result.Error = types.ErrNoSolution
+	}
+
+	d.result.Stats.ExecutionTime = time.Since(d.start)
+	d.result.Decisions = d.decisions
+	d.result.CircuitState.DecisionLevel = len(d.decisions)
+	return d.result
+}
+
+// search propagates the fault effect to a primary output and then justifies the J-frontier
+func (d *dAlgorithm) search() bool {
+	if d.err = checkLimits(d.result, d.config, d.start); d.err != nil {
+		return false
+	}
+	if !performImplication(d.circuit, d.fault, d.config.LearnedImplications, d.result.Stats) {
+		return false
+	}
+
+	if !isFaultDetected(d.circuit) {
+		dFrontier := findDFrontier(d.circuit)
+		blocked := len(dFrontier) > 0
+		dFrontier = pruneDFrontier(dFrontier)
+		if len(dFrontier) == 0 {
+			if blocked {
+				d.result.Stats.XPathBacktracks++
+			}
+			return false
+		}
+		d.result.DFrontier = convertToDFrontierGates(dFrontier, d.pathFinder)
+
+		for _, gate := range dFrontier {
+			cubes := PropagationDCubes(gate)
+			if cube := reconvergentDCube(gate); cube != nil {
+				cubes = append(cubes, cube)
+			}
+			if d.tryCubes(gate, cubes) {
+				return true
+			}
+			if d.err != nil {
+				return false
+			}
+		}
+		return false
+	}
+
+	unjustified := findUnjustifiedLines(d.circuit, d.fault)
+	if len(unjustified) == 0 {
+		return true
+	}
+
+	line := unjustified[0]
+	cubes := SingularCover(line.FanIn)
+	if line == d.fault.Site {
+		cubes = PrimitiveDCubesOfFailure(line.FanIn, d.fault.StuckAt)
+	}
+	return d.tryCubes(line.FanIn, cubes)
+}
+
+// tryCubes applies each consistent cube of the gate as a decision and recurses
+func (d *dAlgorithm) tryCubes(gate *circuit.Gate, cubes []Cube) bool {
+	for _, cube := range cubes {
+		if !cubeCompatible(gate, cube) {
+			continue
+		}
+
+		state := saveCircuitState(d.circuit)
+		applyCube(gate, cube)
+		d.decisions = append(d.decisions, types.Decision{
+			Signal:    gate.Output,
+			Value:     cube.Output(),
+			Level:     len(d.decisions) + 1,
+			TimeStamp: time.Now(),
+		})
+		d.result.Stats.Decisions++
+		if len(d.decisions) > d.result.Stats.MaxDecisionLevel {
+			d.result.Stats.MaxDecisionLevel = len(d.decisions)
+		}
+
+		if d.search() {
+			return true
+		}
+
+		restoreCircuitState(d.circuit, state)
+		d.decisions = d.decisions[:len(d.decisions)-1]
+		if d.err != nil {
+			return false
+		}
+		d.result.Stats.Backtracks++
+	}
+	return false
+}
+
+// cubeCompatible checks if a cube intersects the current values of the gate lines
+func cubeCompatible(gate *circuit.Gate, cube Cube) bool {
+	lines := append(append([]*circuit.Signal{}, gate.Inputs...), gate.Output)
+	for i, signal := range lines {
+		if cube[i] != circuit.X && signal.Value != circuit.X && signal.Value != cube[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// applyCube assigns the cube values to the unassigned gate lines
+func applyCube(gate *circuit.Gate, cube Cube) {
+	lines := append(append([]*circuit.Signal{}, gate.Inputs...), gate.Output)
+	for i, signal := range lines {
+		if cube[i] != circuit.X && signal.Value == circuit.X {
+			signal.Assign(cube[i])
+		}
+	}
+}
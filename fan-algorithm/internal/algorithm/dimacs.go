@@ -0,0 +1,111 @@
+// dimacs.go
+package algorithm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+)
+
+// ExportFaultDIMACS writes the formulation of detecting the fault as DIMACS CNF
+// together with the map from variables to circuit lines
+func ExportFaultDIMACS(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue,
+	cnfWriter, mapWriter io.Writer) (*FaultCNF, error) {
+
+	formula := BuildFaultCNF(c, faultSite, faultValue)
+	comment := fmt.Sprintf("detect %s stuck-at-%d", faultSite.ID, faultValue)
+	if err := formula.CNF.WriteDIMACS(cnfWriter, comment); err != nil {
+		return nil, err
+	}
+	if err := formula.WriteVariableMap(mapWriter); err != nil {
+		return nil, err
+	}
+	return formula, nil
+}
+
+// WriteVariableMap writes one "<variable> <good|faulty> <signal>" line per encoded line
+func (f *FaultCNF) WriteVariableMap(w io.Writer) error {
+	type entry struct {
+		variable int
+		kind     string
+		signal   *circuit.Signal
+	}
+	entries := make([]entry, 0, len(f.GoodVars)+len(f.FaultyVars))
+	for signal, v := range f.GoodVars {
+		entries = append(entries, entry{v, "good", signal})
+	}
+	for signal, v := range f.FaultyVars {
+		entries = append(entries, entry{v, "faulty", signal})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].variable < entries[j].variable
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "c fault %s %d\n", f.Site.ID, f.StuckAt)
+	for _, e := range entries {
+		fmt.Fprintf(bw, "%d %s %s\n", e.variable, e.kind, e.signal.ID)
+	}
+	return bw.Flush()
+}
+
+// ReadVariableMap restores the variable assignment of a formulation written by
+// WriteVariableMap, so a model from an external solver can be mapped back
+func ReadVariableMap(c *circuit.Circuit, r io.Reader) (*FaultCNF, error) {
+	f := &FaultCNF{
+		GoodVars:   make(map[*circuit.Signal]int),
+		FaultyVars: make(map[*circuit.Signal]int),
+	}
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "c" {
+			// The fault header restores the targeted fault
+			if len(fields) == 4 && fields[1] == "fault" {
+				site, err := c.GetSignalByID(fields[2])
+				if err != nil {
+					return nil, err
+				}
+				stuckAt, err := strconv.Atoi(fields[3])
+				if err != nil {
+					return nil, fmt.Errorf("invalid stuck-at value: %s", fields[3])
+				}
+				f.Site = site
+				f.StuckAt = circuit.SignalValue(stuckAt)
+			}
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid variable map line: %s", scanner.Text())
+		}
+		v, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid variable: %s", fields[0])
+		}
+		signal, err := c.GetSignalByID(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		switch fields[1] {
+		case "good":
+			f.GoodVars[signal] = v
+		case "faulty":
+			f.FaultyVars[signal] = v
+		default:
+			return nil, fmt.Errorf("invalid variable kind: %s", fields[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
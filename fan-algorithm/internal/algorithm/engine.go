@@ -0,0 +1,77 @@
+// engine.go
+package algorithm
+
+import (
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/strategy"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// TestGenerator is the common interface of all test generation engines.
+// Every engine reports its result and stats through types.TestResult.
+type TestGenerator interface {
+	Name() string
+	Generate(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *types.TestResult
+}
+
+// FANGenerator runs the FAN algorithm
+type FANGenerator struct {
+	Config    *types.TestGenerationConfig
+	Heuristic strategy.DecisionHeuristic // Nil selects the heuristic named by the config
+}
+
+func NewFANGenerator(config *types.TestGenerationConfig) *FANGenerator {
+	return &FANGenerator{Config: config}
+}
+
+func (g *FANGenerator) Name() string { return "FAN" }
+
+func (g *FANGenerator) Generate(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *types.TestResult {
+	result, _ := FANWithHeuristic(c, faultSite, faultValue, g.Config, g.Heuristic)
+	return result
+}
+
+// PODEMGenerator runs the PODEM algorithm
+type PODEMGenerator struct {
+	Config *types.TestGenerationConfig
+}
+
+func NewPODEMGenerator(config *types.TestGenerationConfig) *PODEMGenerator {
+	return &PODEMGenerator{Config: config}
+}
+
+func (g *PODEMGenerator) Name() string { return "PODEM" }
+
+func (g *PODEMGenerator) Generate(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *types.TestResult {
+	return PODEMWithConfig(c, faultSite, faultValue, g.Config)
+}
+
+// DAlgorithmGenerator runs the Roth D-algorithm
+type DAlgorithmGenerator struct {
+	Config *types.TestGenerationConfig
+}
+
+func NewDAlgorithmGenerator(config *types.TestGenerationConfig) *DAlgorithmGenerator {
+	return &DAlgorithmGenerator{Config: config}
+}
+
+func (g *DAlgorithmGenerator) Name() string { return "D-algorithm" }
+
+func (g *DAlgorithmGenerator) Generate(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *types.TestResult {
+	return DAlgorithmWithConfig(c, faultSite, faultValue, g.Config)
+}
+
+// SATGenerator runs the SAT-based engine with the built-in CDCL solver
+type SATGenerator struct {
+	Config *types.TestGenerationConfig
+}
+
+func NewSATGenerator(config *types.TestGenerationConfig) *SATGenerator {
+	return &SATGenerator{Config: config}
+}
+
+func (g *SATGenerator) Name() string { return "SAT" }
+
+func (g *SATGenerator) Generate(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *types.TestResult {
+	return SATATPGWithConfig(c, faultSite, faultValue, g.Config)
+}
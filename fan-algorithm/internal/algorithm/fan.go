@@ -5,104 +5,370 @@ import (
 
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/sensitization"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/strategy"
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
 )
 
-// FAN algorithm implementation with configuration and enhanced types
+// stuckAtFault describes the fault targeted by a FAN run. The site is nil
+// if the sources carry every fault effect.
+type stuckAtFault struct {
+	Site    *circuit.Signal
+	StuckAt circuit.SignalValue
+	Sources []types.Assignment       // Lines permanently carrying a fault effect, D or D', modelling further faults
+	Require []types.Assignment       // Values the test must set besides exciting the fault
+	cone    map[*circuit.Signal]bool // Fanout cone of the site, built on first use
+}
+
+// effect returns the five-valued value carried by the fault site once activated
+func (f *stuckAtFault) effect() circuit.SignalValue {
+	if f.StuckAt == circuit.ONE {
+		return circuit.D_BAR
+	}
+	return circuit.D
+}
+
+// goodValue returns the fault-free value required at the fault site
+func (f *stuckAtFault) goodValue() circuit.SignalValue {
+	return getOppositeValue(f.StuckAt)
+}
+
+// activate places the fault effect on the fault site
+func (f *stuckAtFault) activate() {
+	if f.Site != nil {
+		f.Site.Assign(f.effect())
+	}
+	for _, source := range f.Sources {
+		source.Signal.Assign(source.Value)
+	}
+	for _, required := range f.Require {
+		required.Signal.Assign(required.Value)
+	}
+}
+
+// inFanoutCone checks if the fault effect can reach the signal
+func (f *stuckAtFault) inFanoutCone(signal *circuit.Signal) bool {
+	if f.Site == nil && len(f.Sources) == 0 {
+		return false
+	}
+	if f.cone == nil {
+		f.cone = make(map[*circuit.Signal]bool)
+		var mark func(*circuit.Signal)
+		mark = func(s *circuit.Signal) {
+			if f.cone[s] {
+				return
+			}
+			f.cone[s] = true
+			for _, fanout := range s.Fanouts {
+				mark(fanout)
+			}
+		}
+		if f.Site != nil {
+			mark(f.Site)
+		}
+		for _, source := range f.Sources {
+			mark(source.Signal)
+		}
+	}
+	return f.cone[signal]
+}
+
+// faultFree returns the fault-free circuit as a fault without effects, the
+// sources keep their good values and leave the lines they drive unchanged
+func (f *stuckAtFault) faultFree() *stuckAtFault {
+	free := &stuckAtFault{}
+	for _, source := range f.Sources {
+		free.Require = append(free.Require, types.Assignment{Signal: source.Signal, Value: goodValueOf(source.Value)})
+	}
+	return free
+}
+
+// FAN algorithm implementation with the default configuration
 func FAN(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *types.TestResult {
+	return FANWithConfig(c, faultSite, faultValue, types.NewTestGenerationConfig())
+}
+
+// FANWithConfig runs the FAN algorithm with the given configuration
+func FANWithConfig(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue,
+	config *types.TestGenerationConfig) *types.TestResult {
+
+	result, _ := FANWithTree(c, faultSite, faultValue, config)
+	return result
+}
+
+// FANWithTree runs the FAN algorithm and also returns its decision tree, which
+// records every branch tried. The search order is selected by the config.
+func FANWithTree(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue,
+	config *types.TestGenerationConfig) (*types.TestResult, *strategy.DecisionTree) {
+
+	return FANWithHeuristic(c, faultSite, faultValue, config, nil)
+}
+
+// FANWithHeuristic runs the FAN algorithm choosing decisions with the given
+// heuristic, nil selects the built-in heuristic named by the config
+func FANWithHeuristic(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue,
+	config *types.TestGenerationConfig, heuristic strategy.DecisionHeuristic) (*types.TestResult, *strategy.DecisionTree) {
+
+	return runFAN(c, &stuckAtFault{Site: faultSite, StuckAt: faultValue}, config, heuristic)
+}
+
+// ConstrainedFAN runs the FAN algorithm for a fault whose test must also set
+// the required values, ErrNoSolution means no test of the fault sets them all
+func ConstrainedFAN(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue,
+	require []types.Assignment, config *types.TestGenerationConfig) *types.TestResult {
+
+	result, _ := runFAN(c, &stuckAtFault{Site: faultSite, StuckAt: faultValue, Require: require}, config, nil)
+	return result
+}
+
+// runFAN runs the FAN algorithm for the given fault
+func runFAN(c *circuit.Circuit, fault *stuckAtFault, config *types.TestGenerationConfig,
+	heuristic strategy.DecisionHeuristic) (*types.TestResult, *strategy.DecisionTree) {
+
+	if heuristic == nil {
+		heuristic = strategy.NewDecisionHeuristic(config.DecisionHeuristic, config.HeuristicSeed)
+	}
 	result := types.NewTestResult()
-	decisionTree := make([]*types.Decision, 0)
-	config := types.NewTestGenerationConfig() // Add config
+	decisionTree := strategy.NewDecisionTree(c)
+	if config.SearchOrder == types.LIMITED_DISCREPANCY_SEARCH {
+		decisionTree.MaxDiscrepancies = 0
+	}
+	pathFinder := sensitization.NewPathFinder(c)
+	clauses := types.NewConflictClauseDB()
+	start := time.Now()
 
-	// Initialize circuit
+	// Initialize circuit and set fault site value, decisions are undone through the trail
 	resetCircuit(c)
+	fault.activate()
+
+	// Values required by unique sensitization on the current branch, in level order
+	sensitized := make([]types.Assignment, 0)
+
+	// restart abandons the current path and starts over from the fault alone,
+	// learned conflict clauses are kept
+	restartInterval := config.RestartInterval
+	restartAt := restartInterval
+	restart := func() {
+		decisionTree.Restart()
+		resetCircuit(c)
+		fault.activate()
+		sensitized = sensitized[:0]
+		result.Stats.Restarts++
+	}
+
+	// retryFrom backtracks from a failure depending on the given decision
+	// levels, returns false once the search space is exhausted
+	retryFrom := func(conflict map[int]bool) bool {
+		if config.SearchOrder == types.RESTART_SEARCH && restartInterval > 0 && result.Stats.Backtracks >= restartAt {
+			// Geometrically growing intervals keep the search complete, the
+			// first decision rotates so the restart begins in another subtree
+			restartInterval *= 2
+			restartAt = result.Stats.Backtracks + restartInterval
+			decisionTree.Rotation++
+			restart()
+			return true
+		}
 
-	// Set fault site value
-	faultSite.Value = circuit.D
-	if faultValue == circuit.ONE {
-		faultSite.Value = circuit.D_BAR
+		if conflict == nil || !config.UseBackjumping {
+			conflict = allLevels(decisionTree.Depth())
+		}
+		if backtrack(decisionTree, conflict, c, result) == nil {
+			// Limited discrepancy search widens the limit until nothing was skipped
+			if decisionTree.Pruned {
+				decisionTree.MaxDiscrepancies++
+				restart()
+				return true
+			}
+			result.Error = types.ErrNoSolution
+			return false
+		}
+
+		// Assignments at the level of the flipped decision and above are undone
+		kept := len(sensitized)
+		for kept > 0 && sensitized[kept-1].Level >= decisionTree.Depth() {
+			kept--
+		}
+		sensitized = sensitized[:kept]
+		return true
+	}
+	retry := func() bool {
+		return retryFrom(nil)
 	}
 
 	for {
-		// Forward implication
-		implResult := performImplication(c, &decisionTree, result)
-		if !implResult {
-			if !backtrack(&decisionTree, c, result) {
+		if err := checkLimits(result, config, start); err != nil {
+			result.Error = err
+			break
+		}
+
+		// Forward and backward implication
+		if !performImplication(c, fault, config.LearnedImplications, result.Stats) {
+			var conflict map[int]bool
+			if config.UseConflictLearning || config.UseBackjumping {
+				assumed := conflictAssignments(decisionTree.Path(), sensitized)
+				var clause *types.ConflictClause
+				clause, conflict = analyzeConflict(c, fault, assumed, config.LearnedImplications)
+				if clause != nil && config.UseConflictLearning {
+					storeConflictClause(clause, clauses, config, result.Stats)
+				}
+				if listener, ok := heuristic.(strategy.ConflictListener); ok && clause != nil {
+					listener.OnConflict(clauseSignals(clause))
+				}
+			}
+			if !retryFrom(conflict) {
+				break
+			}
+			continue
+		}
+
+		// Learned conflict clauses prune decisions that already failed
+		changed, violated := applyConflictClauses(fault, []*types.ConflictClauseDB{clauses, config.ConflictClauses}, result.Stats)
+		if violated != nil {
+			assumed := conflictAssignments(decisionTree.Path(), sensitized)
+			if !retryFrom(clauseLevels(violated, assumed)) {
 				break
 			}
 			continue
 		}
+		if changed {
+			continue
+		}
 
-		// Find D-frontier
+		// Find D-frontier and drop gates without an X-path to a primary output
+		detected := isFaultDetected(c)
 		dFrontier := findDFrontier(c)
-		result.DFrontier = convertToDFrontierGates(dFrontier) // Convert type
+		if !detected {
+			blocked := len(dFrontier) > 0
+			dFrontier = pruneDFrontier(dFrontier)
+			if len(dFrontier) == 0 {
+				if blocked {
+					result.Stats.XPathBacktracks++
+				}
+				if !retry() {
+					break
+				}
+				continue
+			}
+			result.DFrontier = convertToDFrontierGates(dFrontier, pathFinder)
+		}
+
+		// Unique sensitization through the dominators of the D-frontier
+		if config.UseUniqueSensitization && !detected {
+			before := len(result.Implications)
+			changed, ok := applyUniqueSensitization(pathFinder, dFrontier, decisionTree.Depth(), result)
+			sensitized = append(sensitized, result.Implications[before:]...)
+			if !ok {
+				if !retry() {
+					break
+				}
+				continue
+			}
+			if changed {
+				continue
+			}
+		}
 
-		// Check for completion
-		if isTestComplete(c, dFrontier) {
+		// Once the fault is detected and only free lines remain unjustified,
+		// the free regions are justified without further search
+		unjustified := findUnjustifiedLines(c, fault)
+		if detected && allFreeLines(unjustified) {
+			justifyFreeRegions(c, fault, unjustified, decisionTree.Depth(), result)
 			result.Success = true
 			saveTestPattern(c, result)
 			break
 		}
 
-		// Multiple backtrace when D-frontier exists
-		objectives := createObjectives(c, dFrontier)
+		// Multiple backtrace from justification and propagation objectives
+		objectives := createObjectives(c, fault, dFrontier, unjustified, detected)
 		if len(objectives) == 0 {
-			if !backtrack(&decisionTree, c, result) {
+			if !retry() {
 				break
 			}
 			continue
 		}
 
 		backtraceResult := MultipleBacktrace(objectives, c, config)
-		if !handleBacktraceResult(backtraceResult, c, &decisionTree, result) {
-			if !backtrack(&decisionTree, c, result) {
-				break
-			}
+		result.Stats.BacktraceCount++
+		if !handleBacktraceResult(backtraceResult, c, decisionTree, heuristic, result) && !retry() {
+			break
 		}
 	}
 
-	return result
+	result.Stats.ExecutionTime = time.Since(start)
+	result.CircuitState.DecisionLevel = decisionTree.Depth()
+	for _, node := range decisionTree.Path() {
+		result.Decisions = append(result.Decisions, types.Decision{
+			Signal:      node.Signal,
+			Value:       node.Value,
+			Alternative: node.Tried,
+			Level:       node.Level,
+			Conflicts:   node.Conflicts,
+		})
+	}
+	return result, decisionTree
 }
 
-// State management functions
-func saveInitialState(c *circuit.Circuit) *types.CircuitState {
-	state := types.NewCircuitState()
-	for _, signal := range c.Signals {
-		state.SignalValues[signal] = signal.GetValue()
+// checkLimits returns an error once the configured search limits are exceeded
+func checkLimits(result *types.TestResult, config *types.TestGenerationConfig, start time.Time) types.TestGenerationError {
+	if config.MaxDecisions > 0 && result.Stats.Decisions > config.MaxDecisions {
+		return types.ErrMaxDecisions
+	}
+	if config.MaxBacktracks > 0 && result.Stats.Backtracks > config.MaxBacktracks {
+		return types.ErrMaxBacktracks
 	}
+	if config.TimeLimit > 0 && time.Since(start) > config.TimeLimit {
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+// State management functions. Snapshots record the trail position, restoring
+// undoes the assignments made since then.
+func saveCircuitState(c *circuit.Circuit) *types.CircuitState {
+	state := types.NewCircuitState()
+	state.TrailPosition = c.Trail().Len()
 	return state
 }
 
+func restoreCircuitState(c *circuit.Circuit, state *types.CircuitState) {
+	c.Trail().UndoTo(state.TrailPosition)
+}
+
 func saveTestPattern(c *circuit.Circuit, result *types.TestResult) {
+	// Patterns hold fault-free values, a fault effect on an input means its good value
 	for _, signal := range c.PrimaryInputs {
-		result.TestPattern[signal] = signal.GetValue()
+		result.TestPattern[signal] = goodValueOf(signal.GetValue())
 	}
 }
 
-// Backtracking support
-func backtrack(decisionTree *[]*types.Decision, c *circuit.Circuit, result *types.TestResult) bool {
-	if len(*decisionTree) == 0 {
-		return false
-	}
-
-	lastIdx := len(*decisionTree) - 1
-	lastDecision := (*decisionTree)[lastIdx]
-
-	if !lastDecision.Alternative {
-		// Try alternative value
-		lastDecision.Alternative = true
-		lastDecision.Value = getOppositeValue(lastDecision.Value)
-		lastDecision.TimeStamp = time.Now()
+// Backtracking support. The decision tree jumps back to the deepest decision
+// level the conflict depends on and flips it, the trail undoes the assignments
+// of that level and above. Returns nil if no decision is left to flip.
+func backtrack(decisionTree *strategy.DecisionTree, conflict map[int]bool, c *circuit.Circuit,
+	result *types.TestResult) *strategy.DecisionNode {
+
+	backjumps := decisionTree.Backjumps
+	node := decisionTree.Backjump(conflict)
+	result.Stats.Backjumps += decisionTree.Backjumps - backjumps
+	if node == nil {
+		return nil
+	}
+
+	trail := c.Trail()
+	trail.BacktrackTo(node.Level - 1)
+	trail.NewLevel()
+	node.Signal.Assign(node.Value)
+	result.Stats.Backtracks++
+	return node
+}
 
-		// Reset circuit state and replay decisions
-		resetCircuitState(c, *decisionTree, lastIdx)
-		lastDecision.Signal.SetValue(lastDecision.Value)
-		return true
+// allLevels returns every decision level up to the given one, used when the
+// cause of a failure is unknown
+func allLevels(depth int) map[int]bool {
+	levels := make(map[int]bool, depth)
+	for level := 1; level <= depth; level++ {
+		levels[level] = true
 	}
-
-	// Remove last decision and try parent
-	*decisionTree = (*decisionTree)[:lastIdx]
-	return backtrack(decisionTree, c, result)
+	return levels
 }
 
 // D-frontier handling
@@ -134,13 +400,13 @@ func isDFrontierGate(gate *circuit.Gate) bool {
 }
 
 // Type conversion helpers
-func convertToDFrontierGates(gates []*circuit.Gate) []types.DFrontierGate {
+func convertToDFrontierGates(gates []*circuit.Gate, pathFinder *sensitization.PathFinder) []types.DFrontierGate {
 	result := make([]types.DFrontierGate, len(gates))
 	for i, gate := range gates {
 		result[i] = types.DFrontierGate{
 			Gate:        gate,
 			FaultyInput: findFaultyInput(gate),
-			Priority:    calculateGatePriority(gate),
+			Priority:    calculateGatePriority(gate, pathFinder),
 		}
 	}
 	return result
@@ -165,25 +431,12 @@ func findBlockingInputs(gate *circuit.Gate) []*circuit.Signal {
 	return blocking
 }
 
-// Change this function
-func calculateGatePriority(gate *circuit.Gate) int {
-	priority := 0
-	// Get circuit instance from gate
-	var c *circuit.Circuit
-	if gate.Output != nil && gate.Output.FanIn != nil {
-		// Get the circuit instance from any signal/gate
-		// Need to add Circuit field to Gate struct
-		c = gate.Circuit
-	}
-
-	if c != nil {
-		pFinder := sensitization.NewPathFinder(c)
-		paths := pFinder.FindUniqueSensitizationPaths([]*circuit.Gate{gate})
-		if len(paths) > 0 {
-			priority += 10 // Higher priority for gates with unique sensitization paths
-		}
+// calculateGatePriority prefers D-frontier gates with a unique sensitization path
+func calculateGatePriority(gate *circuit.Gate, pathFinder *sensitization.PathFinder) int {
+	if len(pathFinder.FindUniqueSensitizationPaths([]*circuit.Gate{gate})) > 0 {
+		return 10 // Higher priority for gates with unique sensitization paths
 	}
-	return priority
+	return 0
 }
 
 // Test completion check
@@ -216,21 +469,6 @@ func createBacktraceObjectives(c *circuit.Circuit, dFrontier []types.DFrontierGa
 	return objectives
 }
 
-// State reset support
-func resetCircuitState(c *circuit.Circuit, decisions []*types.Decision, upToIndex int) {
-	// Reset all non-primary signals to X
-	for _, signal := range c.Signals {
-		if !signal.IsPrimary {
-			signal.SetValue(circuit.X)
-		}
-	}
-
-	// Replay decisions up to index
-	for i := 0; i <= upToIndex; i++ {
-		decisions[i].Signal.SetValue(decisions[i].Value)
-	}
-}
-
 // Helper functions
 func getOppositeValue(value circuit.SignalValue) circuit.SignalValue {
 	switch value {
@@ -258,101 +496,191 @@ func calculateObjectivePriority(gate *circuit.Gate) int {
 	return priority
 }
 
-// Update handleBacktraceResult function
+// handleBacktraceResult turns the final objective ranked best by the heuristic
+// into a decision. Decisions are restricted to unassigned head lines and primary inputs.
 func handleBacktraceResult(backtraceResult *BacktraceResult, c *circuit.Circuit,
-	decisionTree *[]*types.Decision, result *types.TestResult) bool {
+	decisionTree *strategy.DecisionTree, heuristic strategy.DecisionHeuristic, result *types.TestResult) bool {
 
-	if len(backtraceResult.FinalObjectives) == 0 {
-		return false
-	}
-
-	// Try each objective until one succeeds
+	candidates := make([]strategy.Candidate, 0, len(backtraceResult.FinalObjectives))
 	for _, obj := range backtraceResult.FinalObjectives {
-		decision := &types.Decision{
-			Signal:    obj.Signal,
-			Value:     obj.Value,
-			Level:     result.CircuitState.DecisionLevel + 1,
-			TimeStamp: time.Now(),
-		}
-
-		// Apply decision
-		obj.Signal.SetValue(obj.Value)
-
-		// Check if decision leads to consistency
-		implResult := Implication(c, types.Assignment{
-			Signal: obj.Signal,
-			Value:  obj.Value,
-			Reason: types.DECISION,
-			Level:  result.CircuitState.DecisionLevel + 1,
-		})
-
-		if implResult.Success {
-			*decisionTree = append(*decisionTree, decision)
-			result.CircuitState.DecisionLevel++
-			result.Stats.Decisions++
-			return true
+		if isDecisionCandidate(obj.Signal) {
+			candidates = append(candidates, strategy.Candidate{Signal: obj.Signal, Value: obj.Value})
 		}
+	}
+	if len(candidates) == 0 {
+		return false
+	}
+	ranked := heuristic.Rank(c, candidates)
+	best := ranked[0]
+	if decisionTree.Depth() == 0 {
+		best = ranked[decisionTree.Rotation%len(ranked)]
+	}
 
-		// Reset if implication failed
-		obj.Signal.SetValue(circuit.X)
+	// Apply decision on a new level, consistency is checked by the next implication
+	c.Trail().NewLevel()
+	best.Signal.Assign(best.Value)
+	node := decisionTree.AddDecision(best.Signal, best.Value)
+	result.CircuitState.DecisionLevel = node.Level
+	result.Stats.Decisions++
+	if node.Level > result.Stats.MaxDecisionLevel {
+		result.Stats.MaxDecisionLevel = node.Level
 	}
+	return true
+}
 
-	return false
+// isDecisionCandidate checks if a signal may be assigned by a decision
+func isDecisionCandidate(signal *circuit.Signal) bool {
+	if signal.Value != circuit.X || signal.Uncontrollable {
+		return false
+	}
+	return signal.IsHead || signal.FanIn == nil
 }
 
-func handleUniqueSensitization(c *circuit.Circuit, paths []*circuit.Signal,
-	decisionTree *[]*types.Decision, result *types.TestResult) bool {
+// applyUniqueSensitization assigns the values required by the dominators of the
+// D-frontier. Returns whether any line changed and whether the values are consistent.
+func applyUniqueSensitization(pf *sensitization.PathFinder, dFrontier []*circuit.Gate,
+	level int, result *types.TestResult) (bool, bool) {
 
-	for _, signal := range paths {
-		if signal.FanIn == nil {
-			continue
+	changed := false
+	for _, assignment := range pf.UniqueSensitization(dFrontier, level) {
+		implied, ok := assignUnknown(assignment.Signal, assignment.Value)
+		if !ok {
+			return false, false
 		}
-
-		value := signal.FanIn.GetNonControllingValue()
-		decision := &types.Decision{
-			Signal:    signal,
-			Value:     value,
-			Level:     result.CircuitState.DecisionLevel + 1,
-			TimeStamp: time.Now(),
+		if implied {
+			result.Implications = append(result.Implications, assignment)
+			changed = true
 		}
-
-		*decisionTree = append(*decisionTree, decision)
-		signal.SetValue(value)
-
-		result.CircuitState.DecisionLevel++
-		result.Stats.Decisions++
 	}
-
-	return true
-}
-
-func findUniqueSensitizationPaths(c *circuit.Circuit, dFrontier []*circuit.Gate) []*circuit.Signal {
-	pFinder := sensitization.NewPathFinder(c)
-	paths := pFinder.FindUniqueSensitizationPaths(dFrontier)
-	return pFinder.GetMandatorySignals(paths)
+	return changed, true
 }
 
-// Add performImplication function
-func performImplication(c *circuit.Circuit, decisionTree *[]*types.Decision, result *types.TestResult) bool {
+// performImplication propagates values forward and backward until nothing changes,
+// then applies learned implications if a table is given.
+// Returns false if an inconsistency is found.
+func performImplication(c *circuit.Circuit, fault *stuckAtFault,
+	learned *types.LearnedImplicationTable, stats *types.TestGenerationStats) bool {
 	changed := true
 	for changed {
 		changed = false
 		for _, gate := range c.Gates {
-			oldValue := gate.Output.Value
+			output := gate.Output
 			newValue := evaluateGate(gate)
+			if newValue == circuit.X {
+				continue
+			}
 
-			if oldValue != newValue {
-				if !gate.Output.IsCompatible(newValue) {
+			// The fault site keeps its fault effect, only its good value is checked
+			if output == fault.Site {
+				if newValue != fault.goodValue() {
 					return false
 				}
-				gate.Output.Value = newValue
-				changed = true
+				continue
+			}
+
+			if output.Value == newValue {
+				continue
+			}
+			if output.Value != circuit.X {
+				return false
+			}
+			output.Assign(newValue)
+			changed = true
+		}
+
+		for _, gate := range c.Gates {
+			implied, ok := implyBackward(gate, requiredValue(gate.Output, fault))
+			if !ok {
+				return false
+			}
+			changed = changed || implied
+		}
+
+		if !changed && learned != nil {
+			implied, ok := applyLearnedImplications(c, fault, learned, stats)
+			if !ok {
+				return false
 			}
+			changed = implied
 		}
 	}
 	return true
 }
 
+// requiredValue returns the fault-free value a line must be justified to,
+// or X if the line needs no justification
+func requiredValue(signal *circuit.Signal, fault *stuckAtFault) circuit.SignalValue {
+	if signal == fault.Site {
+		return fault.goodValue()
+	}
+	if signal.Value == circuit.ZERO || signal.Value == circuit.ONE {
+		return signal.Value
+	}
+	return circuit.X
+}
+
+// implyBackward assigns gate inputs whose values are uniquely determined by
+// the required output value. Returns whether any input changed.
+func implyBackward(gate *circuit.Gate, required circuit.SignalValue) (bool, bool) {
+	if required == circuit.X {
+		return false, true
+	}
+
+	switch gate.Type {
+	case circuit.NOT:
+		return assignUnknown(gate.Inputs[0], getOppositeValue(required))
+	case circuit.AND, circuit.OR:
+		nonControlling := gate.GetNonControllingValue()
+		if required == nonControlling {
+			// All inputs must be non-controlling
+			changed := false
+			for _, input := range gate.Inputs {
+				implied, ok := assignUnknown(input, nonControlling)
+				if !ok {
+					return false, false
+				}
+				changed = changed || implied
+			}
+			return changed, true
+		}
+
+		// A single unassigned input must take the controlling value
+		var candidate *circuit.Signal
+		for _, input := range gate.Inputs {
+			switch input.Value {
+			case nonControlling:
+				continue
+			case circuit.X:
+				if candidate != nil {
+					return false, true
+				}
+				candidate = input
+			default:
+				return false, true
+			}
+		}
+		if candidate == nil {
+			return false, true
+		}
+		return assignUnknown(candidate, getOppositeValue(nonControlling))
+	}
+	return false, true
+}
+
+// assignUnknown sets a binary value on a line if it is still unassigned, an
+// unassigned uncontrollable line can't take one. Returns whether the line
+// changed and whether the value is consistent.
+func assignUnknown(signal *circuit.Signal, value circuit.SignalValue) (bool, bool) {
+	if signal.Value == circuit.X {
+		if signal.Uncontrollable {
+			return false, false
+		}
+		signal.Assign(value)
+		return true, true
+	}
+	return false, signal.Value == value
+}
+
 func evaluateGate(gate *circuit.Gate) circuit.SignalValue {
 	switch gate.Type {
 	case circuit.AND:
@@ -467,29 +795,62 @@ func convertToCircuitGates(dFrontier []types.DFrontierGate) []*circuit.Gate {
 	return gates
 }
 
+// resetCircuit sets every signal to X and starts a new trail
 func resetCircuit(c *circuit.Circuit) {
+	trail := c.Trail()
 	for _, signal := range c.Signals {
-		if !signal.IsPrimary {
-			signal.Value = circuit.X
-		}
+		signal.Assign(circuit.X)
 	}
+	trail.Clear()
 }
 
-func createObjectives(c *circuit.Circuit, dFrontier []*circuit.Gate) []*types.BacktraceObjective {
+// createObjectives builds justification objectives for unjustified bound lines
+// and, while the fault is not yet detected, propagation objectives for the
+// highest priority D-frontier gate
+func createObjectives(c *circuit.Circuit, fault *stuckAtFault, dFrontier []*circuit.Gate,
+	unjustified []*circuit.Signal, detected bool) []*types.BacktraceObjective {
+
 	objectives := make([]*types.BacktraceObjective, 0)
-	for _, gate := range dFrontier {
-		obj := &types.BacktraceObjective{
-			Signal:    gate.Output,
-			Value:     circuit.ONE, // Non-controlling value for propagation
-			Priority:  10,
-			ZeroCount: 0,
-			OneCount:  1,
+	for _, signal := range unjustified {
+		if signal.IsFree() {
+			continue
+		}
+		objectives = append(objectives, newObjective(signal, requiredValue(signal, fault), 10))
+	}
+
+	if detected || len(dFrontier) == 0 {
+		return objectives
+	}
+
+	gate := dFrontier[0]
+	for _, candidate := range dFrontier[1:] {
+		if calculateObjectivePriority(candidate) > calculateObjectivePriority(gate) {
+			gate = candidate
+		}
+	}
+	for _, input := range gate.Inputs {
+		if input.Value == circuit.X {
+			objectives = append(objectives, newObjective(input, gate.GetNonControllingValue(), 10))
 		}
-		objectives = append(objectives, obj)
 	}
 	return objectives
 }
 
+// newObjective creates a backtrace objective with its value counts initialized
+func newObjective(signal *circuit.Signal, value circuit.SignalValue, priority int) *types.BacktraceObjective {
+	obj := &types.BacktraceObjective{
+		Signal:   signal,
+		Value:    value,
+		Priority: priority,
+	}
+	if value == circuit.ONE {
+		obj.OneCount = 1
+	} else {
+		obj.ZeroCount = 1
+	}
+	return obj
+}
+
 func allNecessaryAssignmentsMade(c *circuit.Circuit) bool {
 	for _, signal := range c.Signals {
 		if signal.Value == circuit.X && !signal.IsPrimary {
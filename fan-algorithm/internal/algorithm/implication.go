@@ -8,6 +8,14 @@ import (
 
 // Implication performs forward and backward implication
 func Implication(c *circuit.Circuit, assignment types.Assignment) types.TestResult {
+	return ImplicationWithLearning(c, assignment, nil)
+}
+
+// ImplicationWithLearning performs forward and backward implication and also
+// follows the learned implications of every assigned signal
+func ImplicationWithLearning(c *circuit.Circuit, assignment types.Assignment,
+	learned *types.LearnedImplicationTable) types.TestResult {
+
 	result := types.NewTestResult()
 	result.Implications = append(result.Implications, assignment)
 
@@ -37,6 +45,13 @@ func Implication(c *circuit.Circuit, assignment types.Assignment) types.TestResu
 			return *result
 		}
 
+		// Learned indirect implication
+		if learned != nil && !learnedImplicate(current, &queue, learned, result) {
+			result.Success = false
+			result.Error = types.ErrInconsistency
+			return *result
+		}
+
 		result.Stats.Implications++
 	}
 
@@ -51,7 +66,7 @@ func forwardImplicate(signal *circuit.Signal, queue *[]types.Assignment, result
 		}
 
 		gate := fanout.FanIn
-		if gate.Evaluate() {
+		if gate.Imply() {
 			newValue := gate.Output.GetValue()
 			if !signal.IsCompatible(newValue) {
 				return false
@@ -176,3 +191,32 @@ func backwardImplicateNOT(gate *circuit.Gate, signal *circuit.Signal, queue *[]t
 	}
 	return true
 }
+
+func learnedImplicate(current types.Assignment, queue *[]types.Assignment,
+	learned *types.LearnedImplicationTable, result *types.TestResult) bool {
+
+	for _, implication := range learned.Lookup(current.Signal, current.Value) {
+		target := implication.Target
+		if !target.IsUnknown() {
+			if target.GetValue() != implication.TargetValue {
+				return false
+			}
+			continue
+		}
+
+		target.Assign(implication.TargetValue)
+		assignment := types.Assignment{
+			Signal:    target,
+			Value:     implication.TargetValue,
+			Reason:    types.IMPLICATION,
+			Level:     result.CircuitState.DecisionLevel,
+			TimeStamp: time.Now(),
+		}
+
+		*queue = append(*queue, assignment)
+		result.Implications = append(result.Implications, assignment)
+		result.CircuitState.SignalValues[target] = implication.TargetValue
+		result.Stats.LearnedImplications++
+	}
+	return true
+}
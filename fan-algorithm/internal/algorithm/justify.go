@@ -0,0 +1,99 @@
+// justify.go
+package algorithm
+
+import (
+	"time"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// findUnjustifiedLines returns lines whose required value is not yet implied by their inputs
+func findUnjustifiedLines(c *circuit.Circuit, fault *stuckAtFault) []*circuit.Signal {
+	unjustified := make([]*circuit.Signal, 0)
+	for _, gate := range c.Gates {
+		if requiredValue(gate.Output, fault) == circuit.X {
+			continue
+		}
+		if evaluateGate(gate) == circuit.X {
+			unjustified = append(unjustified, gate.Output)
+		}
+	}
+	return unjustified
+}
+
+// isFaultDetected checks if a fault effect reached any primary output
+func isFaultDetected(c *circuit.Circuit) bool {
+	for _, output := range c.PrimaryOutputs {
+		if output.Value == circuit.D || output.Value == circuit.D_BAR {
+			return true
+		}
+	}
+	return false
+}
+
+// allFreeLines checks if every given line lies in a free region
+func allFreeLines(signals []*circuit.Signal) bool {
+	for _, signal := range signals {
+		if !signal.IsFree() {
+			return false
+		}
+	}
+	return true
+}
+
+// justifyFreeRegions justifies the given free lines down to primary inputs.
+// Free regions are fanout-free, so every line is justified without conflict
+// in time linear in the size of its region.
+func justifyFreeRegions(c *circuit.Circuit, fault *stuckAtFault, lines []*circuit.Signal,
+	level int, result *types.TestResult) {
+
+	for _, signal := range lines {
+		justifyLine(signal, requiredValue(signal, fault), level, result)
+	}
+}
+
+// justifyLine assigns inputs of the line's gate so that it evaluates to value
+func justifyLine(signal *circuit.Signal, value circuit.SignalValue, level int, result *types.TestResult) {
+	gate := signal.FanIn
+	if gate == nil || evaluateGate(gate) != circuit.X {
+		return
+	}
+
+	switch gate.Type {
+	case circuit.NOT:
+		justifyInput(gate.Inputs[0], getOppositeValue(value), level, result)
+	case circuit.AND, circuit.OR:
+		nonControlling := gate.GetNonControllingValue()
+		if value == nonControlling {
+			for _, input := range gate.Inputs {
+				justifyInput(input, nonControlling, level, result)
+			}
+			return
+		}
+
+		// One controlling input is enough
+		for _, input := range gate.Inputs {
+			if input.Value == circuit.X {
+				justifyInput(input, getOppositeValue(nonControlling), level, result)
+				return
+			}
+		}
+	}
+}
+
+// justifyInput assigns an unassigned input and keeps justifying towards primary inputs
+func justifyInput(signal *circuit.Signal, value circuit.SignalValue, level int, result *types.TestResult) {
+	if signal.Value == circuit.X {
+		signal.Assign(value)
+		result.Implications = append(result.Implications, types.Assignment{
+			Signal:    signal,
+			Value:     value,
+			Reason:    types.IMPLICATION,
+			Level:     level,
+			TimeStamp: time.Now(),
+		})
+		result.Stats.Implications++
+	}
+	justifyLine(signal, value, level, result)
+}
@@ -0,0 +1,134 @@
+// learning.go
+package algorithm
+
+import (
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// signalLiteral is a value of a signal, the key of learning candidates
+type signalLiteral struct {
+	signal *circuit.Signal
+	value  circuit.SignalValue
+}
+
+// StaticLearning learns indirect implications of the fault-free circuit.
+// For every signal and value, the assignment is implied directly and for each
+// implied value on a learnable gate output the contrapositive is recorded,
+// unless direct implication already derives it. Values that fail implication
+// can't hold in any state, the opposite values are learned as constants.
+func StaticLearning(c *circuit.Circuit) *types.LearnedImplicationTable {
+	table := types.NewLearnedImplicationTable()
+
+	// target=w follows from signal=v, so target=!w implies signal=!v
+	candidates := make(map[signalLiteral][]signalLiteral)
+	antecedents := make([]signalLiteral, 0)
+	for _, signal := range c.Signals {
+		for _, value := range []circuit.SignalValue{circuit.ZERO, circuit.ONE} {
+			implied, ok := directImplications(c, signal, value)
+			if !ok {
+				table.AddConstant(types.LearnedConstant{Signal: signal, Value: getOppositeValue(value)})
+				continue
+			}
+			for _, target := range implied {
+				antecedent := signalLiteral{target, getOppositeValue(target.Value)}
+				if _, seen := candidates[antecedent]; !seen {
+					antecedents = append(antecedents, antecedent)
+				}
+				candidates[antecedent] = append(candidates[antecedent], signalLiteral{signal, getOppositeValue(value)})
+			}
+		}
+	}
+
+	// Nothing to learn if the antecedent is impossible or implies the consequent directly
+	for _, antecedent := range antecedents {
+		if _, ok := directImplications(c, antecedent.signal, antecedent.value); !ok {
+			continue
+		}
+		for _, consequent := range candidates[antecedent] {
+			if consequent.signal.Value == consequent.value {
+				continue
+			}
+			table.Add(types.LearnedImplication{
+				Signal:      antecedent.signal,
+				Value:       antecedent.value,
+				Target:      consequent.signal,
+				TargetValue: consequent.value,
+			})
+		}
+	}
+
+	resetCircuit(c)
+	return table
+}
+
+// directImplications implies signal=value on the cleared circuit and returns
+// the learnable gate outputs it assigns, their values are left on the circuit.
+// Returns false if implication fails.
+func directImplications(c *circuit.Circuit, signal *circuit.Signal, value circuit.SignalValue) ([]*circuit.Signal, bool) {
+	resetCircuit(c)
+	signal.Assign(value)
+	if !performImplication(c, &stuckAtFault{}, nil, nil) {
+		return nil, false
+	}
+
+	implied := make([]*circuit.Signal, 0)
+	for _, gate := range c.Gates {
+		target := gate.Output
+		if target != signal && target.Value != circuit.X && isLearnable(target, target.Value) {
+			implied = append(implied, target)
+		}
+	}
+	return implied, true
+}
+
+// isLearnable checks if a value on a gate output results from all of its inputs.
+// Only then does the opposite value not determine any input by direct implication.
+func isLearnable(signal *circuit.Signal, value circuit.SignalValue) bool {
+	gate := signal.FanIn
+	if gate == nil || gate.Type == circuit.NOT {
+		return false
+	}
+	return value == gate.GetNonControllingValue()
+}
+
+// applyLearnedImplications assigns the learned constants and the targets of
+// learned implications whose antecedent holds. Both hold in the fault-free circuit, so
+// targets the fault effect can reach are left alone.
+func applyLearnedImplications(c *circuit.Circuit, fault *stuckAtFault,
+	learned *types.LearnedImplicationTable, stats *types.TestGenerationStats) (bool, bool) {
+
+	changed := false
+	for _, constant := range learned.Constants() {
+		if fault.inFanoutCone(constant.Signal) {
+			continue
+		}
+		implied, ok := assignUnknown(constant.Signal, constant.Value)
+		if !ok {
+			return false, false
+		}
+		changed = changed || implied
+	}
+	for _, signal := range c.Signals {
+		value := requiredValue(signal, fault)
+		if value == circuit.X {
+			continue
+		}
+		for _, implication := range learned.Lookup(signal, value) {
+			if fault.inFanoutCone(implication.Target) {
+				continue
+			}
+			implied, ok := assignUnknown(implication.Target, implication.TargetValue)
+			if !ok {
+				return false, false
+			}
+			if implied {
+				changed = true
+				if stats != nil {
+					stats.LearnedImplications++
+				}
+			}
+		}
+	}
+	return changed, true
+}
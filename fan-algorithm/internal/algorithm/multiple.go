@@ -0,0 +1,53 @@
+// multiple.go
+package algorithm
+
+import (
+	"fmt"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// MultipleFAN generates a test for several stuck-at faults present at once,
+// each given as its site and stuck value. Every fault is injected into a
+// copy of the circuit as a control point whose control input permanently
+// carries its effect: ANDed with D a line keeps its good value and is 0 in
+// the faulty circuit, ORed with D' it is 1. FAN propagates any of the effects
+// and the five-valued values of the lines in between account for faults
+// masking or reinforcing each other. Returns the result and the copy its
+// pattern refers to, or an error if a site is given more than once.
+func MultipleFAN(c *circuit.Circuit, faults []types.Assignment,
+	config *types.TestGenerationConfig) (*types.TestResult, *circuit.TimeFrames, error) {
+
+	sites := make(map[*circuit.Signal]bool, len(faults))
+	for _, fault := range faults {
+		if sites[fault.Signal] {
+			return nil, nil, fmt.Errorf("line %s carries more than one fault", fault.Signal.ID)
+		}
+		sites[fault.Signal] = true
+	}
+
+	tf := c.Unroll(1)
+	points := make([]circuit.TestPoint, len(faults))
+	for i, fault := range faults {
+		points[i] = circuit.TestPoint{Signal: tf.Copy(0, fault.Signal), Kind: circuit.CONTROL_0_POINT}
+		if fault.Value == circuit.ONE {
+			points[i].Kind = circuit.CONTROL_1_POINT
+		}
+	}
+
+	sources := make([]types.Assignment, len(faults))
+	for i, source := range tf.Circuit.InsertTestPoints(points) {
+		source.Uncontrollable = true
+		sources[i] = types.Assignment{Signal: source, Value: circuit.D}
+		if faults[i].Value == circuit.ONE {
+			sources[i].Value = circuit.D_BAR
+		}
+	}
+	tf.Circuit.IdentifyBoundAndHeadLines()
+
+	// No single fault has to be excited, the sources carry all of them
+	fault := &stuckAtFault{Sources: sources}
+	result, _ := runFAN(tf.Circuit, fault, unrolledConfig(config), nil)
+	return result, tf, nil
+}
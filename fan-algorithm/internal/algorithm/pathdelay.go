@@ -0,0 +1,57 @@
+// pathdelay.go
+package algorithm
+
+import (
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/sensitization"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// PathDelayFAN generates a two-pattern test launching a transition from the
+// initial value at the start of a path of a combinational circuit. The
+// circuit is unrolled over the two patterns. A non-robust test sets every
+// off-path input to its non-controlling value in the second pattern. A robust
+// test also holds them steady where the on-path input turns non-controlling,
+// so the output transition waits for the path whatever the other delays.
+// The path is then sensitized and FAN only has to justify these values
+// together with the final value of the path output, targeted as that output
+// stuck at its initial value. Returns the result and the expansion its
+// pattern refers to.
+func PathDelayFAN(c *circuit.Circuit, path *sensitization.Path, initial circuit.SignalValue, robust bool,
+	config *types.TestGenerationConfig) (*types.TestResult, *circuit.TimeFrames) {
+
+	tf := c.Unroll(2)
+	require := []types.Assignment{{Signal: tf.Copy(0, path.Signals[0]), Value: initial}}
+	final := getOppositeValue(initial)
+	for i, gate := range path.Gates {
+		if gate.Type == circuit.NOT {
+			final = getOppositeValue(final)
+			continue
+		}
+		nonControlling := gate.GetNonControllingValue()
+		for _, input := range path.OffPathInputs(i) {
+			if robust && final == nonControlling {
+				require = append(require, types.Assignment{Signal: tf.Steady(input, nonControlling), Value: circuit.ONE})
+			} else {
+				require = append(require, types.Assignment{Signal: tf.Copy(1, input), Value: nonControlling})
+			}
+		}
+	}
+	tf.Circuit.IdentifyBoundAndHeadLines()
+
+	// Lines required twice must agree, assigning both would overwrite the first
+	required := make(map[*circuit.Signal]circuit.SignalValue, len(require))
+	for _, assignment := range require {
+		if value, ok := required[assignment.Signal]; ok && value != assignment.Value {
+			result := types.NewTestResult()
+			result.Error = types.ErrNoSolution
+			return result, tf
+		}
+		required[assignment.Signal] = assignment.Value
+	}
+
+	output := tf.Copy(1, path.Signals[len(path.Signals)-1])
+	fault := &stuckAtFault{Site: output, StuckAt: getOppositeValue(final), Require: require}
+	result, _ := runFAN(tf.Circuit, fault, unrolledConfig(config), nil)
+	return result, tf
+}
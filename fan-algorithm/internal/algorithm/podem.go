@@ -0,0 +1,185 @@
+// podem.go
+package algorithm
+
+import (
+	"time"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/sensitization"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// PODEM algorithm implementation with the default configuration
+func PODEM(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *types.TestResult {
+	return PODEMWithConfig(c, faultSite, faultValue, types.NewTestGenerationConfig())
+}
+
+// PODEMWithConfig runs PODEM with the given configuration.
+// Decisions are made on primary inputs only and every decision is followed by
+// a five-valued simulation of the faulty circuit.
+func PODEMWithConfig(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue,
+	config *types.TestGenerationConfig) *types.TestResult {
+
+	result := types.NewTestResult()
+	decisions := make([]*types.Decision, 0)
+	fault := &stuckAtFault{Site: faultSite, StuckAt: faultValue}
+	order := c.TopologicalOrder()
+	pathFinder := sensitization.NewPathFinder(c)
+	start := time.Now()
+
+	for {
+		if err := checkLimits(result, config, start); err != nil {
+			result.Error = err
+			break
+		}
+
+		simulateWithFault(c, order, fault, decisions)
+		if isFaultDetected(c) {
+			result.Success = true
+			saveTestPattern(c, result)
+			break
+		}
+
+		// Objective and backtrace to an unassigned primary input
+		if signal, value := podemObjective(c, fault, pathFinder, result); signal != nil {
+			if input, inputValue := podemBacktrace(signal, value); input != nil {
+				decision := &types.Decision{
+					Signal:    input,
+					Value:     inputValue,
+					Level:     len(decisions) + 1,
+					TimeStamp: time.Now(),
+				}
+				decisions = append(decisions, decision)
+				result.Stats.Decisions++
+				result.Stats.BacktraceCount++
+				if decision.Level > result.Stats.MaxDecisionLevel {
+					result.Stats.MaxDecisionLevel = decision.Level
+				}
+				continue
+			}
+		}
+
+		if !podemBacktrack(&decisions, result) {
+			result.Error = types.ErrNoSolution
+			break
+		}
+	}
+
+	result.Stats.ExecutionTime = time.Since(start)
+	result.CircuitState.DecisionLevel = len(decisions)
+	for _, decision := range decisions {
+		result.Decisions = append(result.Decisions, *decision)
+	}
+	return result
+}
+
+// simulateWithFault evaluates the faulty circuit from the primary input decisions
+func simulateWithFault(c *circuit.Circuit, order []*circuit.Signal, fault *stuckAtFault, decisions []*types.Decision) {
+	resetCircuit(c)
+	for _, decision := range decisions {
+		decision.Signal.Assign(decision.Value)
+	}
+
+	for _, signal := range order {
+		if signal.FanIn != nil {
+			signal.Assign(evaluateGate(signal.FanIn))
+		}
+		if signal == fault.Site {
+			signal.Assign(fault.inject(signal.Value))
+		}
+	}
+}
+
+// inject maps the fault-free value of the fault site to its five-valued value
+func (f *stuckAtFault) inject(good circuit.SignalValue) circuit.SignalValue {
+	switch good {
+	case f.goodValue():
+		return f.effect()
+	case f.StuckAt:
+		return f.StuckAt
+	default:
+		return circuit.X
+	}
+}
+
+// podemObjective activates the fault first, then propagates through the D-frontier
+func podemObjective(c *circuit.Circuit, fault *stuckAtFault, pathFinder *sensitization.PathFinder,
+	result *types.TestResult) (*circuit.Signal, circuit.SignalValue) {
+
+	switch fault.Site.Value {
+	case circuit.X:
+		return fault.Site, fault.goodValue()
+	case fault.StuckAt:
+		return nil, circuit.X
+	}
+
+	dFrontier := findDFrontier(c)
+	blocked := len(dFrontier) > 0
+	dFrontier = pruneDFrontier(dFrontier)
+	if len(dFrontier) == 0 {
+		if blocked {
+			result.Stats.XPathBacktracks++
+		}
+		return nil, circuit.X
+	}
+	result.DFrontier = convertToDFrontierGates(dFrontier, pathFinder)
+
+	gate := dFrontier[0]
+	for _, input := range gate.Inputs {
+		if input.Value == circuit.X {
+			return input, gate.GetNonControllingValue()
+		}
+	}
+	return nil, circuit.X
+}
+
+// podemBacktrace maps an objective to a value on an unassigned primary input
+func podemBacktrace(signal *circuit.Signal, value circuit.SignalValue) (*circuit.Signal, circuit.SignalValue) {
+	for signal.FanIn != nil {
+		gate := signal.FanIn
+		switch gate.Type {
+		case circuit.NOT:
+			signal = gate.Inputs[0]
+			value = getOppositeValue(value)
+		case circuit.AND, circuit.OR:
+			// Setting every input is needed for the non-controlling output value,
+			// so the hardest input is tried first to fail early
+			allInputs := value == gate.GetNonControllingValue()
+			signal = selectBacktraceInput(gate, allInputs)
+			if signal == nil {
+				return nil, circuit.X
+			}
+		default:
+			return nil, circuit.X
+		}
+	}
+
+	if signal.Value != circuit.X {
+		return nil, circuit.X
+	}
+	return signal, value
+}
+
+// selectBacktraceInput returns the hardest or easiest unassigned input of a gate
+func selectBacktraceInput(gate *circuit.Gate, hardest bool) *circuit.Signal {
+	if hardest {
+		return gate.GetHardestNonControllingInput()
+	}
+	return gate.GetEasiestControllingInput()
+}
+
+// podemBacktrack flips the most recent untried decision, dropping exhausted ones
+func podemBacktrack(decisions *[]*types.Decision, result *types.TestResult) bool {
+	for len(*decisions) > 0 {
+		last := (*decisions)[len(*decisions)-1]
+		if !last.Alternative {
+			last.Alternative = true
+			last.Value = getOppositeValue(last.Value)
+			last.TimeStamp = time.Now()
+			result.Stats.Backtracks++
+			return true
+		}
+		*decisions = (*decisions)[:len(*decisions)-1]
+	}
+	return false
+}
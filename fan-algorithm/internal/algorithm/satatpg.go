@@ -0,0 +1,216 @@
+// satatpg.go
+package algorithm
+
+import (
+	"time"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/sat"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// FaultCNF is the satisfiability formulation of detecting a stuck-at fault.
+// It encodes a miter of the fault-free and faulty cones: the good copy covers
+// the fanin cone of every primary output the fault can reach, the faulty copy
+// only the fanout cone of the fault site.
+type FaultCNF struct {
+	CNF        *sat.CNF
+	Site       *circuit.Signal
+	StuckAt    circuit.SignalValue
+	GoodVars   map[*circuit.Signal]int // Fault-free value of each encoded line
+	FaultyVars map[*circuit.Signal]int // Faulty value of each line in the fanout cone
+	Outputs    []*circuit.Signal       // Primary outputs observing the fault
+}
+
+// BuildFaultCNF encodes "detect the stuck-at fault" with a Tseitin encoding per gate type
+func BuildFaultCNF(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *FaultCNF {
+	f := &FaultCNF{
+		CNF:        sat.NewCNF(),
+		Site:       faultSite,
+		StuckAt:    faultValue,
+		GoodVars:   make(map[*circuit.Signal]int),
+		FaultyVars: make(map[*circuit.Signal]int),
+		Outputs:    make([]*circuit.Signal, 0),
+	}
+
+	// Fanout cone of the fault site and the outputs it reaches
+	fanoutCone := make(map[*circuit.Signal]bool)
+	var markFanout func(*circuit.Signal)
+	markFanout = func(signal *circuit.Signal) {
+		if fanoutCone[signal] {
+			return
+		}
+		fanoutCone[signal] = true
+		for _, fanout := range signal.Fanouts {
+			markFanout(fanout)
+		}
+	}
+	markFanout(faultSite)
+	for _, output := range c.PrimaryOutputs {
+		if fanoutCone[output] {
+			f.Outputs = append(f.Outputs, output)
+		}
+	}
+	if len(f.Outputs) == 0 {
+		f.CNF.AddClause() // Unobservable fault, the formula is unsatisfiable
+		return f
+	}
+
+	// Fanin cone of the observing outputs
+	faninCone := make(map[*circuit.Signal]bool)
+	var markFanin func(*circuit.Signal)
+	markFanin = func(signal *circuit.Signal) {
+		if faninCone[signal] {
+			return
+		}
+		faninCone[signal] = true
+		if signal.FanIn != nil {
+			for _, input := range signal.FanIn.Inputs {
+				markFanin(input)
+			}
+		}
+	}
+	for _, output := range f.Outputs {
+		markFanin(output)
+	}
+
+	order := c.TopologicalOrder()
+
+	// Good circuit
+	for _, signal := range order {
+		if faninCone[signal] {
+			f.GoodVars[signal] = f.CNF.NewVar()
+		}
+	}
+	for _, signal := range order {
+		if faninCone[signal] && signal.FanIn != nil {
+			encodeGate(f.CNF, signal.FanIn, f.GoodVars)
+		}
+	}
+
+	// Faulty circuit, lines outside the fanout cone share their good variable
+	faultyVar := func(signal *circuit.Signal) int {
+		if v, ok := f.FaultyVars[signal]; ok {
+			return v
+		}
+		return f.GoodVars[signal]
+	}
+	for _, signal := range order {
+		if !faninCone[signal] || !fanoutCone[signal] {
+			continue
+		}
+		f.FaultyVars[signal] = f.CNF.NewVar()
+		if signal == faultSite {
+			f.CNF.AddClause(literal(f.FaultyVars[signal], faultValue == circuit.ONE))
+			continue
+		}
+		inputs := make(map[*circuit.Signal]int)
+		for _, input := range signal.FanIn.Inputs {
+			inputs[input] = faultyVar(input)
+		}
+		inputs[signal] = f.FaultyVars[signal]
+		encodeGate(f.CNF, signal.FanIn, inputs)
+	}
+
+	// At least one observing output differs between both circuits
+	detect := make([]int, 0, len(f.Outputs))
+	for _, output := range f.Outputs {
+		d := f.CNF.NewVar()
+		encodeXOR(f.CNF, d, f.GoodVars[output], f.FaultyVars[output])
+		detect = append(detect, d)
+	}
+	f.CNF.AddClause(detect...)
+
+	return f
+}
+
+// PatternFromModel reads the primary input values from a satisfying assignment.
+// Inputs outside the encoded cone are left unspecified.
+func (f *FaultCNF) PatternFromModel(c *circuit.Circuit, model []bool) map[*circuit.Signal]circuit.SignalValue {
+	pattern := make(map[*circuit.Signal]circuit.SignalValue)
+	for _, input := range c.PrimaryInputs {
+		v, ok := f.GoodVars[input]
+		switch {
+		case !ok || v >= len(model):
+			pattern[input] = circuit.X
+		case model[v]:
+			pattern[input] = circuit.ONE
+		default:
+			pattern[input] = circuit.ZERO
+		}
+	}
+	return pattern
+}
+
+func literal(v int, positive bool) int {
+	if positive {
+		return v
+	}
+	return -v
+}
+
+// encodeGate adds the Tseitin clauses of a gate, vars maps its lines to variables
+func encodeGate(cnf *sat.CNF, gate *circuit.Gate, vars map[*circuit.Signal]int) {
+	out := vars[gate.Output]
+
+	switch gate.Type {
+	case circuit.NOT:
+		in := vars[gate.Inputs[0]]
+		cnf.AddClause(out, in)
+		cnf.AddClause(-out, -in)
+	case circuit.AND, circuit.OR:
+		// AND: out -> in_i, all in_i -> out. OR is the dual.
+		sign := 1
+		if gate.Type == circuit.OR {
+			sign = -1
+		}
+		long := []int{sign * out}
+		for _, input := range gate.Inputs {
+			in := vars[input]
+			cnf.AddClause(-sign*out, sign*in)
+			long = append(long, -sign*in)
+		}
+		cnf.AddClause(long...)
+	}
+}
+
+// encodeXOR adds the clauses of d = a XOR b
+func encodeXOR(cnf *sat.CNF, d, a, b int) {
+	cnf.AddClause(-d, a, b)
+	cnf.AddClause(-d, -a, -b)
+	cnf.AddClause(d, -a, b)
+	cnf.AddClause(d, a, -b)
+}
+
+// SATATPG generates a test with the built-in CDCL solver using the default configuration
+func SATATPG(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *types.TestResult {
+	return SATATPGWithConfig(c, faultSite, faultValue, types.NewTestGenerationConfig())
+}
+
+// SATATPGWithConfig either finds a test pattern or proves the fault redundant
+func SATATPGWithConfig(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue,
+	config *types.TestGenerationConfig) *types.TestResult {
+
+	result := types.NewTestResult()
+	start := time.Now()
+
+	formula := BuildFaultCNF(c, faultSite, faultValue)
+	solver := sat.NewSolver(formula.CNF)
+	solver.MaxConflicts = config.SATConflictLimit
+
+	switch solver.Solve() {
+	case sat.SATISFIABLE:
+		result.Success = true
+		result.TestPattern = formula.PatternFromModel(c, solver.Model())
+	case sat.UNSATISFIABLE:
+		result.Error = types.ErrNoSolution
+	default:
+		result.Error = types.ErrMaxBacktracks
+	}
+
+	result.Stats.Decisions = solver.Stats.Decisions
+	result.Stats.Backtracks = solver.Stats.Conflicts
+	result.Stats.Implications = solver.Stats.Propagations
+	result.Stats.ExecutionTime = time.Since(start)
+	return result
+}
@@ -0,0 +1,127 @@
+// timeframe.go
+package algorithm
+
+import (
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// SequentialTest is a test sequence for a fault of a sequential circuit
+type SequentialTest struct {
+	Result          *types.TestResult                         // FAN result on the unrolled circuit, the last one tried if no test was found
+	Frames          int                                       // Clock cycles of the test
+	ActivationFrame int                                       // Cycle exciting the fault, the cycles before it initialize the circuit
+	Sequence        []map[*circuit.Signal]circuit.SignalValue // Primary input values of every cycle
+	Load            map[*circuit.FlipFlop]circuit.SignalValue // Values of the scanned flip-flops before the first cycle
+}
+
+// Initialization returns the input sequence driving the circuit from its
+// unknown initial state into the state the fault is excited from
+func (t *SequentialTest) Initialization() []map[*circuit.Signal]circuit.SignalValue {
+	return t.Sequence[:t.ActivationFrame]
+}
+
+// TimeFrameGenerator generates tests for sequential circuits by running FAN on
+// their time-frame expansion. The fault is present in every frame, FAN excites
+// it in the activation frame and it may be excited in the others as well.
+// The number of frames grows up to MaxTimeFrames.
+type TimeFrameGenerator struct {
+	Config *types.TestGenerationConfig
+}
+
+func NewTimeFrameGenerator(config *types.TestGenerationConfig) *TimeFrameGenerator {
+	return &TimeFrameGenerator{Config: config}
+}
+
+// Generate searches a test sequence with one frame, then two and so on, trying
+// every activation frame from the earliest. Faults without a test within the
+// frame limit end with ErrFrameLimit, more frames may still detect them.
+func (g *TimeFrameGenerator) Generate(c *circuit.Circuit, faultSite *circuit.Signal, faultValue circuit.SignalValue) *SequentialTest {
+	config := unrolledConfig(g.Config)
+	test := &SequentialTest{Result: types.NewTestResult()}
+	for frames := 1; frames <= config.MaxTimeFrames; frames++ {
+		for activation := 0; activation < frames; activation++ {
+			tf, fault := unrollWithFault(c, faultSite, faultValue, frames, activation)
+			result, _ := runFAN(tf.Circuit, fault, config, nil)
+			test.Result = result
+			if result.Success {
+				test.Frames = frames
+				test.ActivationFrame = activation
+				test.Sequence, test.Load = sequenceOf(tf, result)
+				return test
+			}
+		}
+	}
+
+	test.Result.Error = types.ErrFrameLimit
+	return test
+}
+
+// unrolledConfig copies the config for runs on an unrolled circuit, learned
+// implications and clauses refer to the lines of the sequential circuit
+func unrolledConfig(config *types.TestGenerationConfig) *types.TestGenerationConfig {
+	unrolled := *config
+	unrolled.LearnedImplications = nil
+	unrolled.ConflictClauses = nil
+	return &unrolled
+}
+
+// unrollWithFault unrolls the circuit and places the fault in every frame. The
+// copy of the site in the activation frame is the site FAN excites. In the
+// other frames the copy gets a control point whose control input permanently
+// carries the fault effect: ANDed with D it keeps its good value and is 0 in
+// the faulty circuit, ORed with D' it is 1 in the faulty circuit.
+func unrollWithFault(c *circuit.Circuit, site *circuit.Signal, stuckAt circuit.SignalValue,
+	frames, activation int) (*circuit.TimeFrames, *stuckAtFault) {
+
+	tf := c.Unroll(frames)
+	fault := &stuckAtFault{Site: tf.Copy(activation, site), StuckAt: stuckAt}
+
+	kind := circuit.CONTROL_0_POINT
+	if stuckAt == circuit.ONE {
+		kind = circuit.CONTROL_1_POINT
+	}
+	points := make([]circuit.TestPoint, 0, frames-1)
+	for frame := 0; frame < frames; frame++ {
+		if frame != activation {
+			points = append(points, circuit.TestPoint{Signal: tf.Copy(frame, site), Kind: kind})
+		}
+	}
+	for _, source := range tf.Circuit.InsertTestPoints(points) {
+		source.Uncontrollable = true
+		fault.Sources = append(fault.Sources, types.Assignment{Signal: source, Value: fault.effect()})
+	}
+	tf.Circuit.IdentifyBoundAndHeadLines()
+	return tf, fault
+}
+
+// sequenceOf reads the input values of every cycle and the scan load from a
+// test pattern of the unrolled circuit
+func sequenceOf(tf *circuit.TimeFrames, result *types.TestResult) ([]map[*circuit.Signal]circuit.SignalValue,
+	map[*circuit.FlipFlop]circuit.SignalValue) {
+
+	c := tf.Original
+	sequence := make([]map[*circuit.Signal]circuit.SignalValue, tf.Frames())
+	for frame := range sequence {
+		sequence[frame] = make(map[*circuit.Signal]circuit.SignalValue, len(c.PrimaryInputs))
+		for _, input := range c.PrimaryInputs {
+			sequence[frame][input] = valueOr(result.TestPattern, tf.Copy(frame, input))
+		}
+	}
+
+	load := make(map[*circuit.FlipFlop]circuit.SignalValue)
+	for _, ff := range c.FlipFlops {
+		if c.ScanChainOf(ff) != nil {
+			load[ff] = valueOr(result.TestPattern, tf.InitialState(ff))
+		}
+	}
+	return sequence, load
+}
+
+// valueOr returns the value of the signal in the pattern, X if it has none
+func valueOr(pattern map[*circuit.Signal]circuit.SignalValue, signal *circuit.Signal) circuit.SignalValue {
+	if value, ok := pattern[signal]; ok {
+		return value
+	}
+	return circuit.X
+}
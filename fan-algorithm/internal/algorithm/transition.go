@@ -0,0 +1,38 @@
+// transition.go
+package algorithm
+
+import (
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// TransitionFAN generates a two-pattern test for a transition fault of a scan
+// design, the launch mode is taken from the config. The circuit is unrolled
+// over an initialization and a launch frame: FAN sets the site to its initial
+// value in the first and detects it stuck at that value in the second, where
+// the late transition leaves it. Returns the result and the expansion its
+// pattern refers to.
+func TransitionFAN(c *circuit.Circuit, site *circuit.Signal, initial circuit.SignalValue,
+	config *types.TestGenerationConfig) (*types.TestResult, *circuit.TimeFrames) {
+
+	tf := c.Unroll(2)
+	if config.LaunchMode == types.LAUNCH_ON_SHIFT {
+		tf = c.UnrollLaunchOnShift()
+	}
+
+	initialization, launch := tf.Copy(0, site), tf.Copy(1, site)
+	if initialization == launch {
+		// A primary input held through launch-on-shift makes no transition
+		result := types.NewTestResult()
+		result.Error = types.ErrNoSolution
+		return result, tf
+	}
+
+	fault := &stuckAtFault{
+		Site:    launch,
+		StuckAt: initial,
+		Require: []types.Assignment{{Signal: initialization, Value: initial}},
+	}
+	result, _ := runFAN(tf.Circuit, fault, unrolledConfig(config), nil)
+	return result, tf
+}
@@ -0,0 +1,44 @@
+// xpath.go
+package algorithm
+
+import (
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+)
+
+// pruneDFrontier removes D-frontier gates that can no longer reach any primary
+// output through X-valued lines
+func pruneDFrontier(dFrontier []*circuit.Gate) []*circuit.Gate {
+	pruned := make([]*circuit.Gate, 0, len(dFrontier))
+	memo := make(map[*circuit.Signal]bool)
+	for _, gate := range dFrontier {
+		if hasXPath(gate.Output, memo) {
+			pruned = append(pruned, gate)
+		}
+	}
+	return pruned
+}
+
+// hasXPath checks if an X-valued line reaches a primary output through X-valued lines only
+func hasXPath(signal *circuit.Signal, memo map[*circuit.Signal]bool) bool {
+	if result, ok := memo[signal]; ok {
+		return result
+	}
+	// Mark before descending so shared subpaths are only explored once
+	memo[signal] = false
+
+	if signal.Value != circuit.X {
+		return false
+	}
+	// Lines reached through fanouts are never primary inputs
+	if signal.IsPrimary {
+		memo[signal] = true
+		return true
+	}
+	for _, fanout := range signal.Fanouts {
+		if hasXPath(fanout, memo) {
+			memo[signal] = true
+			return true
+		}
+	}
+	return false
+}
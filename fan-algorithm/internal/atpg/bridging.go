@@ -0,0 +1,273 @@
+// bridging.go
+package atpg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/algorithm"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// BridgeType is the behaviour of two shorted nets
+type BridgeType int
+
+const (
+	WIRED_AND BridgeType = iota // Both nets carry the AND of their drivers
+	WIRED_OR                    // Both nets carry the OR of their drivers
+	DOMINANT                    // The stronger driver of A forces its value onto B
+)
+
+// BridgingFault shorts two nets of the circuit
+type BridgingFault struct {
+	A    *circuit.Signal
+	B    *circuit.Signal
+	Type BridgeType
+}
+
+func (f BridgingFault) String() string {
+	switch f.Type {
+	case WIRED_AND:
+		return fmt.Sprintf("%s-%s/and", f.A.ID, f.B.ID)
+	case WIRED_OR:
+		return fmt.Sprintf("%s-%s/or", f.A.ID, f.B.ID)
+	default:
+		return fmt.Sprintf("%s-%s/dom", f.A.ID, f.B.ID)
+	}
+}
+
+// resolve returns the value of a bridged net from the values its own driver
+// and the driver of the other net put on it
+func (f BridgingFault) resolve(net *circuit.Signal, own, other circuit.SignalValue) circuit.SignalValue {
+	a, b := own, other
+	if net == f.B {
+		a, b = other, own
+	}
+	switch f.Type {
+	case WIRED_AND:
+		if a == circuit.ZERO || b == circuit.ZERO {
+			return circuit.ZERO
+		}
+		if a == circuit.ONE && b == circuit.ONE {
+			return circuit.ONE
+		}
+		return circuit.X
+	case WIRED_OR:
+		if a == circuit.ONE || b == circuit.ONE {
+			return circuit.ONE
+		}
+		if a == circuit.ZERO && b == circuit.ZERO {
+			return circuit.ZERO
+		}
+		return circuit.X
+	default:
+		return a
+	}
+}
+
+// bridgeExcitation is one way of exciting a bridge: the site takes the stuck
+// value while the other net keeps the value that overrides it
+type bridgeExcitation struct {
+	Site    *circuit.Signal
+	StuckAt circuit.SignalValue
+	Other   *circuit.Signal
+	Value   circuit.SignalValue
+}
+
+// excitations reduces the bridge to stuck-at faults constrained by the other
+// net. A wired-AND pulls the net at 1 down to the 0 of the other, a wired-OR
+// pulls it up, a dominant bridge forces both values of A onto B.
+func (f BridgingFault) excitations() []bridgeExcitation {
+	switch f.Type {
+	case WIRED_AND:
+		return []bridgeExcitation{
+			{Site: f.A, StuckAt: circuit.ZERO, Other: f.B, Value: circuit.ZERO},
+			{Site: f.B, StuckAt: circuit.ZERO, Other: f.A, Value: circuit.ZERO},
+		}
+	case WIRED_OR:
+		return []bridgeExcitation{
+			{Site: f.A, StuckAt: circuit.ONE, Other: f.B, Value: circuit.ONE},
+			{Site: f.B, StuckAt: circuit.ONE, Other: f.A, Value: circuit.ONE},
+		}
+	default:
+		return []bridgeExcitation{
+			{Site: f.B, StuckAt: circuit.ZERO, Other: f.A, Value: circuit.ZERO},
+			{Site: f.B, StuckAt: circuit.ONE, Other: f.A, Value: circuit.ONE},
+		}
+	}
+}
+
+// IsFeedback checks if one net of the bridge feeds the other, the bridge then
+// closes a loop that may latch or oscillate
+func (f BridgingFault) IsFeedback() bool {
+	return reaches(f.A, f.B) || reaches(f.B, f.A)
+}
+
+// reaches checks if a path leads from one signal to the other
+func reaches(from, to *circuit.Signal) bool {
+	visited := make(map[*circuit.Signal]bool)
+	stack := []*circuit.Signal{from}
+	for len(stack) > 0 {
+		signal := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if signal == to {
+			return true
+		}
+		for _, fanout := range signal.Fanouts {
+			if !visited[fanout] {
+				visited[fanout] = true
+				stack = append(stack, fanout)
+			}
+		}
+	}
+	return false
+}
+
+// BridgingFaults returns the faults of the given types on every candidate
+// pair, dominant bridges in both directions
+func BridgingFaults(pairs [][2]*circuit.Signal, bridgeTypes ...BridgeType) []BridgingFault {
+	faults := make([]BridgingFault, 0, 2*len(pairs)*len(bridgeTypes))
+	for _, pair := range pairs {
+		for _, bridgeType := range bridgeTypes {
+			faults = append(faults, BridgingFault{A: pair[0], B: pair[1], Type: bridgeType})
+			if bridgeType == DOMINANT {
+				faults = append(faults, BridgingFault{A: pair[1], B: pair[0], Type: bridgeType})
+			}
+		}
+	}
+	return faults
+}
+
+// LoadBridgingPairs reads candidate pairs of shorted nets from a file
+func LoadBridgingPairs(c *circuit.Circuit, path string) ([][2]*circuit.Signal, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadBridgingPairs(c, file)
+}
+
+// ReadBridgingPairs reads candidate pairs of shorted nets, one pair of signal
+// names per line separated by blanks or a comma. Lines starting with # are
+// comments.
+func ReadBridgingPairs(c *circuit.Circuit, r io.Reader) ([][2]*circuit.Signal, error) {
+	pairs := make([][2]*circuit.Signal, 0)
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if len(names) != 2 {
+			return nil, fmt.Errorf("line %d: expected two signals, got %q", number, line)
+		}
+		var pair [2]*circuit.Signal
+		for i, name := range names {
+			signal, err := c.GetSignalByID(name)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", number, err)
+			}
+			pair[i] = signal
+		}
+		if pair[0] == pair[1] {
+			return nil, fmt.Errorf("line %d: signal %s bridged with itself", number, names[0])
+		}
+		pairs = append(pairs, pair)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// BridgingResult holds the outcome of test generation for one bridging fault
+type BridgingResult struct {
+	Fault  BridgingFault
+	Status FaultStatus
+	Result *types.TestResult
+	Scan   *ScanPattern // Scan test of a detected fault in full-scan mode
+}
+
+// BridgingReport summarizes bridging fault test generation
+type BridgingReport struct {
+	Results   []*BridgingResult
+	Detected  int
+	Redundant int
+	Aborted   int
+	Duration  time.Duration
+}
+
+// Coverage returns the fraction of faults detected
+func (r *BridgingReport) Coverage() float64 {
+	if len(r.Results) == 0 {
+		return 0
+	}
+	return float64(r.Detected) / float64(len(r.Results))
+}
+
+// RunBridgingFaults generates tests for bridging faults on the circuit tests
+// are generated on. Sequential circuits need full scan, without it every
+// fault is aborted.
+func (d *Driver) RunBridgingFaults(faults []BridgingFault) *BridgingReport {
+	report := &BridgingReport{Results: make([]*BridgingResult, 0, len(faults))}
+	start := time.Now()
+	fs := NewFaultSimulator(d.Circuit)
+
+	for _, fault := range faults {
+		bridgingResult := &BridgingResult{Fault: fault, Status: ABORTED, Result: types.NewTestResult()}
+		if d.Sequential == nil {
+			bridgingResult = d.runBridgingFault(fault, fs)
+		}
+		if bridgingResult.Status == DETECTED && d.Core != d.Circuit {
+			// Simulation only fails on combinational loops, which the core has none of
+			scan, err := NewScanPattern(d.Circuit, bridgingResult.Result.TestPattern)
+			if err == nil {
+				bridgingResult.Scan = scan
+			}
+		}
+
+		switch bridgingResult.Status {
+		case DETECTED:
+			report.Detected++
+		case REDUNDANT:
+			report.Redundant++
+		case ABORTED:
+			report.Aborted++
+		}
+		report.Results = append(report.Results, bridgingResult)
+	}
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+// runBridgingFault tries every excitation of the bridge with FAN, the other
+// net is required to keep its value. A net outside the fanout cone of the
+// site keeps it, so a bridge without feedback is redundant once every
+// excitation is. Feedback bridges are never proven redundant and their tests
+// are only accepted once fault simulation confirms them.
+func (d *Driver) runBridgingFault(fault BridgingFault, fs *FaultSimulator) *BridgingResult {
+	bridgingResult := &BridgingResult{Fault: fault, Status: REDUNDANT}
+	feedback := fault.IsFeedback()
+	for _, excitation := range fault.excitations() {
+		require := []types.Assignment{{Signal: d.coreSignal(excitation.Other), Value: excitation.Value}}
+		result := algorithm.ConstrainedFAN(d.Core, d.coreSignal(excitation.Site), excitation.StuckAt, require, d.Config)
+		result.TestPattern = d.circuitPattern(result.TestPattern)
+		bridgingResult.Result = result
+		if result.Success && (!feedback || fs.DetectsBridge(result.TestPattern, fault)) {
+			bridgingResult.Status = DETECTED
+			return bridgingResult
+		}
+		if feedback || isAborted(result.Error) {
+			bridgingResult.Status = ABORTED
+		}
+	}
+	return bridgingResult
+}
@@ -0,0 +1,238 @@
+// driver.go
+package atpg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/algorithm"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// Fault represents a single stuck-at fault
+type Fault struct {
+	Site    *circuit.Signal
+	StuckAt circuit.SignalValue
+}
+
+func (f Fault) String() string {
+	return fmt.Sprintf("%s/sa%d", f.Site.ID, f.StuckAt)
+}
+
+// FaultStatus represents the classification of a fault after test generation
+type FaultStatus int
+
+const (
+	DETECTED  FaultStatus = iota // A test pattern was found
+	REDUNDANT                    // The fault was proven untestable
+	ABORTED                      // Search limits were hit before a decision
+)
+
+// FaultResult holds the outcome of test generation for one fault
+type FaultResult struct {
+	Fault    Fault
+	Status   FaultStatus
+	Engine   string // Name of the engine that produced the final result
+	Result   *types.TestResult
+	Scan     *ScanPattern              // Scan test of a detected fault in full-scan mode
+	Sequence *algorithm.SequentialTest // Test sequence of a fault of a sequential circuit without full scan
+}
+
+// Report summarizes a driver run
+type Report struct {
+	Results       []*FaultResult
+	Detected      int
+	Redundant     int
+	Aborted       int
+	FallbackRuns  int // Faults routed to the fallback engine
+	RandomRuns    int // Faults detected by random patterns
+	RandomSkipped int // Faults predicted random-pattern resistant, not simulated
+	Testability   *TestabilityReport
+	Duration      time.Duration
+}
+
+// Coverage returns the fraction of faults detected
+func (r *Report) Coverage() float64 {
+	if len(r.Results) == 0 {
+		return 0
+	}
+	return float64(r.Detected) / float64(len(r.Results))
+}
+
+// Driver runs test generation over a fault list. Faults the primary engine
+// aborts on are routed to the fallback engine when one is configured.
+type Driver struct {
+	Circuit    *circuit.Circuit
+	Core       *circuit.Circuit // Circuit tests are generated on, the combinational core in full-scan mode
+	Config     *types.TestGenerationConfig
+	Generator  algorithm.TestGenerator
+	Fallback   algorithm.TestGenerator
+	Sequential *algorithm.TimeFrameGenerator // Used instead of the engines for sequential circuits without full scan
+	scan       *circuit.ScanCore
+}
+
+// NewDriver creates a driver using FAN, backed by the SAT engine if enabled in the config.
+// Static learning is run once here so every fault shares the learned implications,
+// conflict clauses are shared across faults if enabled. In full-scan mode
+// tests are generated on the combinational core of a sequential circuit,
+// otherwise test sequences are generated on its time-frame expansion.
+func NewDriver(c *circuit.Circuit, config *types.TestGenerationConfig) *Driver {
+	d := &Driver{
+		Circuit:   c,
+		Core:      c,
+		Config:    config,
+		Generator: algorithm.NewFANGenerator(config),
+	}
+	switch {
+	case config.FullScan && c.IsSequential():
+		d.scan = c.CombinationalCore()
+		d.Core = d.scan.Circuit
+	case c.IsSequential():
+		d.Sequential = algorithm.NewTimeFrameGenerator(config)
+	}
+	if config.UseSATFallback && d.Sequential == nil {
+		d.Fallback = algorithm.NewSATGenerator(config)
+	}
+	if config.UseStaticLearning && config.LearnedImplications == nil && d.Sequential == nil {
+		config.LearnedImplications = algorithm.StaticLearning(d.Core)
+	}
+	if config.ShareConflictClauses && config.ConflictClauses == nil {
+		config.ConflictClauses = types.NewConflictClauseDB()
+	}
+	return d
+}
+
+// AllFaults returns both stuck-at faults on every signal of the circuit
+func AllFaults(c *circuit.Circuit) []Fault {
+	faults := make([]Fault, 0, 2*len(c.Signals))
+	for _, signal := range c.Signals {
+		faults = append(faults,
+			Fault{Site: signal, StuckAt: circuit.ZERO},
+			Fault{Site: signal, StuckAt: circuit.ONE})
+	}
+	return faults
+}
+
+// Run generates tests for every fault in the list. If the config asks for
+// random patterns, they are fault simulated first and only the faults they
+// miss go to the generator. Random patterns are not applied to sequential
+// circuits without full scan.
+func (d *Driver) Run(faults []Fault) *Report {
+	report := &Report{Results: make([]*FaultResult, 0, len(faults))}
+	start := time.Now()
+
+	randomDetected := make(map[Fault]*FaultResult)
+	if d.Config.RandomPatterns > 0 && d.Sequential == nil {
+		randomDetected = d.runRandomPatterns(faults, report)
+	}
+
+	for _, fault := range faults {
+		faultResult, ok := randomDetected[fault]
+		if ok {
+			report.RandomRuns++
+		} else {
+			faultResult = d.RunFault(fault)
+			if faultResult.Engine != d.Generator.Name() {
+				report.FallbackRuns++
+			}
+		}
+
+		if faultResult.Status == DETECTED && d.Core != d.Circuit {
+			// Simulation only fails on combinational loops, which the core has none of
+			scan, err := NewScanPattern(d.Circuit, faultResult.Result.TestPattern)
+			if err == nil {
+				faultResult.Scan = scan
+			}
+		}
+
+		switch faultResult.Status {
+		case DETECTED:
+			report.Detected++
+		case REDUNDANT:
+			report.Redundant++
+		case ABORTED:
+			report.Aborted++
+		}
+		report.Results = append(report.Results, faultResult)
+	}
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+// RunFault generates a test for a single fault
+func (d *Driver) RunFault(fault Fault) *FaultResult {
+	if d.Sequential != nil {
+		sequence := d.Sequential.Generate(d.Circuit, fault.Site, fault.StuckAt)
+		faultResult := &FaultResult{
+			Fault:    fault,
+			Engine:   d.Generator.Name(),
+			Result:   sequence.Result,
+			Sequence: sequence,
+		}
+		faultResult.Status = classify(faultResult.Result)
+		return faultResult
+	}
+
+	faultResult := &FaultResult{
+		Fault:  fault,
+		Engine: d.Generator.Name(),
+		Result: d.Generator.Generate(d.Core, d.coreSignal(fault.Site), fault.StuckAt),
+	}
+	faultResult.Status = classify(faultResult.Result)
+
+	if faultResult.Status == ABORTED && d.Fallback != nil {
+		faultResult.Engine = d.Fallback.Name()
+		faultResult.Result = d.Fallback.Generate(d.Core, d.coreSignal(fault.Site), fault.StuckAt)
+		faultResult.Status = classify(faultResult.Result)
+	}
+	faultResult.Result.TestPattern = d.circuitPattern(faultResult.Result.TestPattern)
+	return faultResult
+}
+
+// coreSignal returns the line of the core tests are generated on for a line
+// of the circuit
+func (d *Driver) coreSignal(signal *circuit.Signal) *circuit.Signal {
+	if d.scan == nil {
+		return signal
+	}
+	return d.scan.Copy(signal)
+}
+
+// circuitPattern keys a pattern generated on the core by the lines of the
+// circuit
+func (d *Driver) circuitPattern(pattern map[*circuit.Signal]circuit.SignalValue) map[*circuit.Signal]circuit.SignalValue {
+	if d.scan == nil || pattern == nil {
+		return pattern
+	}
+	mapped := make(map[*circuit.Signal]circuit.SignalValue, len(pattern))
+	for signal, value := range pattern {
+		mapped[d.scan.OriginalOf(signal)] = value
+	}
+	return mapped
+}
+
+// classify maps a test generation result to a fault status
+func classify(result *types.TestResult) FaultStatus {
+	if result.Success {
+		return DETECTED
+	}
+	if isAborted(result.Error) {
+		return ABORTED
+	}
+	return REDUNDANT
+}
+
+// isAborted checks if the error means the engine gave up rather than proved redundancy
+func isAborted(err types.TestGenerationError) bool {
+	if err == nil {
+		return false
+	}
+	switch err.Code() {
+	case types.ErrMaxDecisions.Code(), types.ErrMaxBacktracks.Code(), types.ErrTimeout.Code(),
+		types.ErrFrameLimit.Code():
+		return true
+	}
+	return false
+}
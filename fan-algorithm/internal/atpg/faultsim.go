@@ -0,0 +1,318 @@
+// faultsim.go
+package atpg
+
+import (
+	"math/bits"
+	"math/rand"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// FaultSimulator checks which faults a pattern detects. Values are computed
+// on its own arrays, the signal values of the circuit are left untouched.
+type FaultSimulator struct {
+	Circuit *circuit.Circuit
+	order   []*circuit.Signal
+	index   map[*circuit.Signal]int
+	inputs  []*circuit.Signal
+	outputs []int
+}
+
+// NewFaultSimulator creates a fault simulator for the circuit. Flip-flops are
+// cut as in full scan: their outputs are inputs of the patterns and their
+// data inputs are observed like primary outputs.
+func NewFaultSimulator(c *circuit.Circuit) *FaultSimulator {
+	fs := &FaultSimulator{
+		Circuit: c,
+		order:   c.TopologicalOrder(),
+		index:   make(map[*circuit.Signal]int),
+	}
+	for i, signal := range fs.order {
+		fs.index[signal] = i
+	}
+
+	added := make(map[*circuit.Signal]bool)
+	for _, input := range append(append([]*circuit.Signal{}, c.PrimaryInputs...), c.PseudoPrimaryInputs()...) {
+		if !added[input] {
+			added[input] = true
+			fs.inputs = append(fs.inputs, input)
+		}
+	}
+	observed := make(map[*circuit.Signal]bool)
+	for _, output := range append(append([]*circuit.Signal{}, c.PrimaryOutputs...), c.PseudoPrimaryOutputs()...) {
+		if !observed[output] {
+			observed[output] = true
+			fs.outputs = append(fs.outputs, fs.index[output])
+		}
+	}
+	return fs
+}
+
+// Simulate returns the value of every signal under the pattern, with the fault
+// injected unless its site is nil. Inputs missing from the pattern are X.
+func (fs *FaultSimulator) Simulate(pattern map[*circuit.Signal]circuit.SignalValue, fault Fault) map[*circuit.Signal]circuit.SignalValue {
+	values := fs.simulate(pattern, fault)
+	result := make(map[*circuit.Signal]circuit.SignalValue, len(values))
+	for i, signal := range fs.order {
+		result[signal] = values[i]
+	}
+	return result
+}
+
+// Detects checks if the pattern detects the fault: some primary output has a
+// known fault-free value and the opposite known value in the faulty circuit
+func (fs *FaultSimulator) Detects(pattern map[*circuit.Signal]circuit.SignalValue, fault Fault) bool {
+	good := fs.simulate(pattern, Fault{})
+	faulty := fs.simulate(pattern, fault)
+	for _, output := range fs.outputs {
+		if good[output] != circuit.X && faulty[output] != circuit.X && good[output] != faulty[output] {
+			return true
+		}
+	}
+	return false
+}
+
+// Coverage returns the faults detected by at least one of the patterns.
+// Faults are dropped once detected.
+func (fs *FaultSimulator) Coverage(patterns []map[*circuit.Signal]circuit.SignalValue, faults []Fault) []Fault {
+	detected := make([]Fault, 0)
+	remaining := append([]Fault{}, faults...)
+	for _, pattern := range patterns {
+		left := remaining[:0]
+		for _, fault := range remaining {
+			if fs.Detects(pattern, fault) {
+				detected = append(detected, fault)
+			} else {
+				left = append(left, fault)
+			}
+		}
+		remaining = left
+	}
+	return detected
+}
+
+// RandomPatterns fault simulates n random patterns, 64 at a time, and returns
+// the faults detected with the first pattern detecting each
+func (fs *FaultSimulator) RandomPatterns(faults []Fault, n int, seed int64) map[Fault]*types.TestResult {
+	detected := make(map[Fault]*types.TestResult)
+	remaining := append([]Fault{}, faults...)
+	random := rand.New(rand.NewSource(seed))
+
+	for start := 0; start < n && len(remaining) > 0; start += 64 {
+		valid := ^uint64(0)
+		if left := n - start; left < 64 {
+			valid = uint64(1)<<left - 1
+		}
+		inputs := make(map[*circuit.Signal]uint64)
+		for _, input := range fs.inputs {
+			inputs[input] = random.Uint64()
+		}
+		good := fs.simulateWords(inputs, Fault{})
+
+		left := remaining[:0]
+		for _, fault := range remaining {
+			mask := fs.detectWords(good, inputs, fault) & valid
+			if mask == 0 {
+				left = append(left, fault)
+				continue
+			}
+			detected[fault] = fs.randomResult(inputs, bits.TrailingZeros64(mask))
+		}
+		remaining = left
+	}
+	return detected
+}
+
+// randomResult builds the test result of one pattern out of a block of 64
+func (fs *FaultSimulator) randomResult(inputs map[*circuit.Signal]uint64, bit int) *types.TestResult {
+	pattern := make(map[*circuit.Signal]circuit.SignalValue)
+	for input, word := range inputs {
+		pattern[input] = circuit.SignalValue(word >> bit & 1)
+	}
+
+	result := types.NewTestResult()
+	result.Success = true
+	result.TestPattern = pattern
+	return result
+}
+
+// simulate evaluates the circuit in three-valued logic with all the faults injected
+func (fs *FaultSimulator) simulate(pattern map[*circuit.Signal]circuit.SignalValue, faults ...Fault) []circuit.SignalValue {
+	values := make([]circuit.SignalValue, len(fs.order))
+	for i, signal := range fs.order {
+		if stuckAt, ok := stuckValue(signal, faults); ok {
+			values[i] = stuckAt
+			continue
+		}
+		switch {
+		case signal.FanIn == nil:
+			values[i] = circuit.X
+			if value, ok := pattern[signal]; ok && (value == circuit.ZERO || value == circuit.ONE) {
+				values[i] = value
+			}
+		default:
+			values[i] = fs.evaluate(signal.FanIn, values)
+		}
+	}
+	return values
+}
+
+// stuckValue returns the value a fault holds the signal at
+func stuckValue(signal *circuit.Signal, faults []Fault) (circuit.SignalValue, bool) {
+	for _, fault := range faults {
+		if fault.Site == signal {
+			return fault.StuckAt, true
+		}
+	}
+	return circuit.X, false
+}
+
+func (fs *FaultSimulator) evaluate(gate *circuit.Gate, values []circuit.SignalValue) circuit.SignalValue {
+	if gate.Type == circuit.NOT {
+		switch values[fs.index[gate.Inputs[0]]] {
+		case circuit.ZERO:
+			return circuit.ONE
+		case circuit.ONE:
+			return circuit.ZERO
+		default:
+			return circuit.X
+		}
+	}
+
+	// A controlling input decides the output, otherwise all inputs must be known
+	controlling, nonControlling := circuit.ZERO, circuit.ONE
+	if gate.Type == circuit.OR {
+		controlling, nonControlling = circuit.ONE, circuit.ZERO
+	}
+	unknown := false
+	for _, input := range gate.Inputs {
+		switch values[fs.index[input]] {
+		case controlling:
+			return controlling
+		case circuit.X:
+			unknown = true
+		}
+	}
+	if unknown {
+		return circuit.X
+	}
+	return nonControlling
+}
+
+// simulateWords evaluates 64 fully specified patterns at once, bit i of a
+// word holds the value under pattern i
+func (fs *FaultSimulator) simulateWords(inputs map[*circuit.Signal]uint64, fault Fault) []uint64 {
+	words := make([]uint64, len(fs.order))
+	for i, signal := range fs.order {
+		switch {
+		case signal == fault.Site:
+			words[i] = 0
+			if fault.StuckAt == circuit.ONE {
+				words[i] = ^uint64(0)
+			}
+		case signal.FanIn == nil:
+			words[i] = inputs[signal]
+		default:
+			gate := signal.FanIn
+			switch gate.Type {
+			case circuit.AND:
+				words[i] = ^uint64(0)
+				for _, input := range gate.Inputs {
+					words[i] &= words[fs.index[input]]
+				}
+			case circuit.OR:
+				words[i] = 0
+				for _, input := range gate.Inputs {
+					words[i] |= words[fs.index[input]]
+				}
+			case circuit.NOT:
+				words[i] = ^words[fs.index[gate.Inputs[0]]]
+			}
+		}
+	}
+	return words
+}
+
+// detectWords returns the patterns among 64 that detect the fault, as a bit mask
+func (fs *FaultSimulator) detectWords(good []uint64, inputs map[*circuit.Signal]uint64, fault Fault) uint64 {
+	faulty := fs.simulateWords(inputs, fault)
+	var mask uint64
+	for _, output := range fs.outputs {
+		mask |= good[output] ^ faulty[output]
+	}
+	return mask
+}
+
+// DetectsBridge checks if the pattern detects the bridging fault
+func (fs *FaultSimulator) DetectsBridge(pattern map[*circuit.Signal]circuit.SignalValue, fault BridgingFault) bool {
+	good := fs.simulate(pattern, Fault{})
+	faulty := fs.simulateBridge(pattern, fault)
+	for _, output := range fs.outputs {
+		if good[output] != circuit.X && faulty[output] != circuit.X && good[output] != faulty[output] {
+			return true
+		}
+	}
+	return false
+}
+
+// BridgingCoverage returns the bridging faults detected by at least one of
+// the patterns. Faults are dropped once detected.
+func (fs *FaultSimulator) BridgingCoverage(patterns []map[*circuit.Signal]circuit.SignalValue, faults []BridgingFault) []BridgingFault {
+	detected := make([]BridgingFault, 0)
+	remaining := append([]BridgingFault{}, faults...)
+	for _, pattern := range patterns {
+		left := remaining[:0]
+		for _, fault := range remaining {
+			if fs.DetectsBridge(pattern, fault) {
+				detected = append(detected, fault)
+			} else {
+				left = append(left, fault)
+			}
+		}
+		remaining = left
+	}
+	return detected
+}
+
+// simulateBridge evaluates the circuit with both nets of the bridge resolved
+// from their drivers. Evaluation is repeated from all X until no value
+// changes, so the loop closed by a feedback bridge settles where it can and
+// leaves X where it would latch or oscillate.
+func (fs *FaultSimulator) simulateBridge(pattern map[*circuit.Signal]circuit.SignalValue, fault BridgingFault) []circuit.SignalValue {
+	values := make([]circuit.SignalValue, len(fs.order))
+	for i := range values {
+		values[i] = circuit.X
+	}
+	drivers := map[*circuit.Signal]circuit.SignalValue{fault.A: circuit.X, fault.B: circuit.X}
+
+	for changed := true; changed; {
+		changed = false
+		for i, signal := range fs.order {
+			value := circuit.X
+			if signal.FanIn == nil {
+				if v, ok := pattern[signal]; ok && (v == circuit.ZERO || v == circuit.ONE) {
+					value = v
+				}
+			} else {
+				value = fs.evaluate(signal.FanIn, values)
+			}
+			if own, bridged := drivers[signal]; bridged {
+				if own != value {
+					drivers[signal] = value
+					changed = true
+				}
+				other := fault.A
+				if signal == fault.A {
+					other = fault.B
+				}
+				value = fault.resolve(signal, value, drivers[other])
+			}
+			if values[i] != value {
+				values[i] = value
+				changed = true
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,156 @@
+// multiple.go
+package atpg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/algorithm"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// MultipleFault is a set of stuck-at faults on distinct lines present at once
+type MultipleFault struct {
+	Faults []Fault
+}
+
+func (f MultipleFault) String() string {
+	names := make([]string, len(f.Faults))
+	for i, fault := range f.Faults {
+		names[i] = fault.String()
+	}
+	return strings.Join(names, "+")
+}
+
+// DoubleFaults returns every pair of the faults on distinct lines
+func DoubleFaults(faults []Fault) []MultipleFault {
+	doubles := make([]MultipleFault, 0)
+	for i, first := range faults {
+		for _, second := range faults[i+1:] {
+			if first.Site != second.Site {
+				doubles = append(doubles, MultipleFault{Faults: []Fault{first, second}})
+			}
+		}
+	}
+	return doubles
+}
+
+// MultipleResult holds the outcome of test generation for one multiple fault
+type MultipleResult struct {
+	Fault  MultipleFault
+	Status FaultStatus
+	Result *types.TestResult
+	Scan   *ScanPattern // Scan test of a detected fault in full-scan mode
+}
+
+// MultipleReport summarizes multiple fault test generation
+type MultipleReport struct {
+	Results   []*MultipleResult
+	Detected  int
+	Redundant int // Faults masking each other under every pattern, or each redundant alone
+	Aborted   int
+	Duration  time.Duration
+}
+
+// Coverage returns the fraction of faults detected
+func (r *MultipleReport) Coverage() float64 {
+	if len(r.Results) == 0 {
+		return 0
+	}
+	return float64(r.Detected) / float64(len(r.Results))
+}
+
+// RunMultipleFaults generates tests for multiple faults on the circuit tests
+// are generated on. Sequential circuits need full scan, without it every
+// fault is aborted. A multiple fault with two faults on a line is an error.
+func (d *Driver) RunMultipleFaults(faults []MultipleFault) (*MultipleReport, error) {
+	report := &MultipleReport{Results: make([]*MultipleResult, 0, len(faults))}
+	start := time.Now()
+
+	for _, fault := range faults {
+		multipleResult := &MultipleResult{Fault: fault, Status: ABORTED, Result: types.NewTestResult()}
+		if d.Sequential == nil {
+			var err error
+			if multipleResult, err = d.runMultipleFault(fault); err != nil {
+				return nil, fmt.Errorf("%s: %v", fault, err)
+			}
+		}
+		if multipleResult.Status == DETECTED && d.Core != d.Circuit {
+			// Simulation only fails on combinational loops, which the core has none of
+			scan, err := NewScanPattern(d.Circuit, multipleResult.Result.TestPattern)
+			if err == nil {
+				multipleResult.Scan = scan
+			}
+		}
+
+		switch multipleResult.Status {
+		case DETECTED:
+			report.Detected++
+		case REDUNDANT:
+			report.Redundant++
+		case ABORTED:
+			report.Aborted++
+		}
+		report.Results = append(report.Results, multipleResult)
+	}
+
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// runMultipleFault runs FAN with all the faults injected, the pattern of a
+// detected fault is mapped back from the faulty copy to the inputs of the core
+// and keyed by the lines of the circuit
+func (d *Driver) runMultipleFault(fault MultipleFault) (*MultipleResult, error) {
+	sites := make([]types.Assignment, len(fault.Faults))
+	for i, single := range fault.Faults {
+		sites[i] = types.Assignment{Signal: d.coreSignal(single.Site), Value: single.StuckAt}
+	}
+	result, tf, err := algorithm.MultipleFAN(d.Core, sites, d.Config)
+	if err != nil {
+		return nil, err
+	}
+	multipleResult := &MultipleResult{Fault: fault, Status: classify(result), Result: result}
+
+	if result.Success {
+		pattern := make(map[*circuit.Signal]circuit.SignalValue, len(d.Core.PrimaryInputs))
+		for _, input := range d.Core.PrimaryInputs {
+			pattern[input] = valueIn(result.TestPattern, tf.Copy(0, input))
+		}
+		result.TestPattern = d.circuitPattern(pattern)
+	}
+	return multipleResult, nil
+}
+
+// DetectsMultiple checks if the pattern detects the faults present together
+func (fs *FaultSimulator) DetectsMultiple(pattern map[*circuit.Signal]circuit.SignalValue, fault MultipleFault) bool {
+	good := fs.simulate(pattern)
+	faulty := fs.simulate(pattern, fault.Faults...)
+	for _, output := range fs.outputs {
+		if good[output] != circuit.X && faulty[output] != circuit.X && good[output] != faulty[output] {
+			return true
+		}
+	}
+	return false
+}
+
+// MultipleCoverage returns the multiple faults detected by at least one of
+// the patterns. Faults are dropped once detected.
+func (fs *FaultSimulator) MultipleCoverage(patterns []map[*circuit.Signal]circuit.SignalValue, faults []MultipleFault) []MultipleFault {
+	detected := make([]MultipleFault, 0)
+	remaining := append([]MultipleFault{}, faults...)
+	for _, pattern := range patterns {
+		left := remaining[:0]
+		for _, fault := range remaining {
+			if fs.DetectsMultiple(pattern, fault) {
+				detected = append(detected, fault)
+			} else {
+				left = append(left, fault)
+			}
+		}
+		remaining = left
+	}
+	return detected
+}
@@ -0,0 +1,239 @@
+// pathdelay.go
+package atpg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/algorithm"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/sensitization"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// PathDelayFault delays a transition along a whole path past the capture edge
+type PathDelayFault struct {
+	Path   *sensitization.Path
+	Rising bool // The transition at the start of the path rises
+}
+
+func (f PathDelayFault) String() string {
+	ids := make([]string, len(f.Path.Signals))
+	for i, signal := range f.Path.Signals {
+		ids[i] = signal.ID
+	}
+	if f.Rising {
+		return fmt.Sprintf("%s/rise", strings.Join(ids, "-"))
+	}
+	return fmt.Sprintf("%s/fall", strings.Join(ids, "-"))
+}
+
+// InitialValue returns the value the start of the path has before the transition
+func (f PathDelayFault) InitialValue() circuit.SignalValue {
+	if f.Rising {
+		return circuit.ZERO
+	}
+	return circuit.ONE
+}
+
+// PathDelayFaults returns the rising and falling faults of every path
+func PathDelayFaults(paths []*sensitization.Path) []PathDelayFault {
+	faults := make([]PathDelayFault, 0, 2*len(paths))
+	for _, path := range paths {
+		faults = append(faults, PathDelayFault{Path: path, Rising: true}, PathDelayFault{Path: path, Rising: false})
+	}
+	return faults
+}
+
+// PathTestability classifies a path delay fault after test generation
+type PathTestability int
+
+const (
+	ROBUST     PathTestability = iota // A test detects the delay whatever the delays off the path
+	NON_ROBUST                        // A test detects the delay if the other paths are not slow as well
+	UNTESTABLE                        // No test sensitizes the path, not even non-robustly
+	UNDECIDED                         // Search limits were hit before a decision
+)
+
+// PathDelayResult holds the outcome of test generation for one path delay fault
+type PathDelayResult struct {
+	Fault          PathDelayFault
+	Testability    PathTestability
+	Result         *types.TestResult
+	Initialization map[*circuit.Signal]circuit.SignalValue // Pattern applied before the transition
+	Launch         map[*circuit.Signal]circuit.SignalValue // Pattern launching the transition
+}
+
+// PathDelayReport summarizes path delay test generation
+type PathDelayReport struct {
+	Results    []*PathDelayResult
+	Robust     int
+	NonRobust  int
+	Untestable int
+	Undecided  int
+	Duration   time.Duration
+}
+
+// Coverage returns the fraction of faults with a robust or non-robust test
+func (r *PathDelayReport) Coverage() float64 {
+	if len(r.Results) == 0 {
+		return 0
+	}
+	return float64(r.Robust+r.NonRobust) / float64(len(r.Results))
+}
+
+// LongestPaths returns the k longest paths of the circuit tests are generated on
+func (d *Driver) LongestPaths(k int) []*sensitization.Path {
+	paths := sensitization.NewPathFinder(d.Core).LongestPaths(k)
+	if d.scan == nil {
+		return paths
+	}
+	for i, path := range paths {
+		paths[i] = mapPath(path, d.scan.OriginalOf)
+	}
+	return paths
+}
+
+// mapPath returns a copy of the path with every line mapped, each gate is
+// the one driving its mapped output
+func mapPath(path *sensitization.Path, mapping func(*circuit.Signal) *circuit.Signal) *sensitization.Path {
+	mapped := &sensitization.Path{
+		Gates:   make([]*circuit.Gate, len(path.Gates)),
+		Signals: make([]*circuit.Signal, len(path.Signals)),
+		Score:   path.Score,
+		Delay:   path.Delay,
+	}
+	for i, signal := range path.Signals {
+		mapped.Signals[i] = mapping(signal)
+	}
+	for i, gate := range path.Gates {
+		mapped.Gates[i] = mapping(gate.Output).FanIn
+	}
+	return mapped
+}
+
+// RunPathDelayFaults generates two-pattern tests for faults on paths of the
+// circuit tests are generated on, trying a robust test first. In full-scan
+// mode both patterns are applied to the combinational core, which needs
+// enhanced scan, otherwise they are two consecutive cycles.
+func (d *Driver) RunPathDelayFaults(faults []PathDelayFault) *PathDelayReport {
+	report := &PathDelayReport{Results: make([]*PathDelayResult, 0, len(faults))}
+	start := time.Now()
+
+	for _, fault := range faults {
+		pathResult := d.runPathDelayFault(fault)
+		switch pathResult.Testability {
+		case ROBUST:
+			report.Robust++
+		case NON_ROBUST:
+			report.NonRobust++
+		case UNTESTABLE:
+			report.Untestable++
+		case UNDECIDED:
+			report.Undecided++
+		}
+		report.Results = append(report.Results, pathResult)
+	}
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+// runPathDelayFault classifies one fault. A fault whose robust search
+// aborted is non-robust at best once a non-robust test is found.
+func (d *Driver) runPathDelayFault(fault PathDelayFault) *PathDelayResult {
+	pathResult := &PathDelayResult{Fault: fault, Testability: UNDECIDED}
+	path := fault.Path
+	if d.scan != nil {
+		path = mapPath(path, d.scan.Copy)
+	}
+	robust, tf := algorithm.PathDelayFAN(d.Core, path, fault.InitialValue(), true, d.Config)
+	pathResult.Result = robust
+	if robust.Success {
+		pathResult.Testability = ROBUST
+	} else {
+		var nonRobust *types.TestResult
+		nonRobust, tf = algorithm.PathDelayFAN(d.Core, path, fault.InitialValue(), false, d.Config)
+		pathResult.Result = nonRobust
+		switch {
+		case nonRobust.Success:
+			pathResult.Testability = NON_ROBUST
+		case !isAborted(robust.Error) && !isAborted(nonRobust.Error):
+			pathResult.Testability = UNTESTABLE
+		}
+	}
+
+	if pathResult.Result.Success {
+		pathResult.Initialization = make(map[*circuit.Signal]circuit.SignalValue)
+		pathResult.Launch = make(map[*circuit.Signal]circuit.SignalValue)
+		for _, input := range d.Core.PrimaryInputs {
+			line := input
+			if d.scan != nil {
+				line = d.scan.OriginalOf(input)
+			}
+			pathResult.Initialization[line] = valueIn(pathResult.Result.TestPattern, tf.Copy(0, input))
+			pathResult.Launch[line] = valueIn(pathResult.Result.TestPattern, tf.Copy(1, input))
+		}
+	}
+	return pathResult
+}
+
+// SensitizesPath checks if a pair of patterns of a combinational circuit tests
+// the path delay fault: the start of the path makes the transition and the
+// off-path inputs are non-controlling under the second pattern. A robust test
+// also needs them free of hazards where the on-path input turns non-controlling.
+func (fs *FaultSimulator) SensitizesPath(initialization, launch map[*circuit.Signal]circuit.SignalValue,
+	fault PathDelayFault, robust bool) bool {
+
+	first, second := fs.simulate(initialization, Fault{}), fs.simulate(launch, Fault{})
+	start := fs.index[fault.Path.Signals[0]]
+	if first[start] != fault.InitialValue() || second[start] == circuit.X || second[start] == fault.InitialValue() {
+		return false
+	}
+
+	steady := fs.steady(first, second)
+	for i, gate := range fault.Path.Gates {
+		if gate.Type == circuit.NOT {
+			continue
+		}
+		nonControlling := gate.GetNonControllingValue()
+		holds := robust && second[fs.index[fault.Path.Signals[i]]] == nonControlling
+		for _, input := range fault.Path.OffPathInputs(i) {
+			if second[fs.index[input]] != nonControlling || holds && !steady[nonControlling][fs.index[input]] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// steady marks the lines that keep value 0 or 1 from the first pattern to the
+// second without a hazard: inputs with the value in both, gates with a
+// controlling input steady at the controlling value or all inputs steady at
+// the non-controlling one
+func (fs *FaultSimulator) steady(first, second []circuit.SignalValue) [2][]bool {
+	steady := [2][]bool{make([]bool, len(fs.order)), make([]bool, len(fs.order))}
+	for i, signal := range fs.order {
+		for _, value := range []circuit.SignalValue{circuit.ZERO, circuit.ONE} {
+			gate := signal.FanIn
+			switch {
+			case gate == nil:
+				steady[value][i] = first[i] == value && second[i] == value
+			case gate.Type == circuit.NOT:
+				steady[value][i] = steady[1-value][fs.index[gate.Inputs[0]]]
+			default:
+				// Steady at the controlling value if any input is, otherwise if all are
+				any := gate.IsControllingValue(value)
+				steady[value][i] = !any
+				for _, input := range gate.Inputs {
+					if steady[value][fs.index[input]] == any {
+						steady[value][i] = any
+						break
+					}
+				}
+			}
+		}
+	}
+	return steady
+}
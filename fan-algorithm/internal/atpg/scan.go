@@ -0,0 +1,285 @@
+// scan.go
+package atpg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+)
+
+// CaptureCycle is one functional clock cycle of a scan test
+type CaptureCycle struct {
+	Inputs  map[*circuit.Signal]circuit.SignalValue   // Primary input values
+	Outputs map[*circuit.Signal]circuit.SignalValue   // Expected primary output values, X is not compared
+	Domains []string                                  // Clock domains pulsed at the end of the cycle
+	State   map[*circuit.FlipFlop]circuit.SignalValue // Flip-flop values during the cycle, filled in by Simulate
+}
+
+// ScanPattern is a full-scan test: the values loaded into the flip-flops,
+// the capture cycles applied in functional mode and the values expected in
+// the flip-flops when they are unloaded. A launch-on-shift test shifts once
+// more after the load, with the primary inputs of its first cycle applied.
+type ScanPattern struct {
+	Load   map[*circuit.FlipFlop]circuit.SignalValue
+	Launch map[*circuit.ScanChain]circuit.SignalValue // Scan inputs of the launching shift, nil if there is none
+	Cycles []*CaptureCycle
+	Unload map[*circuit.FlipFlop]circuit.SignalValue // Expected values, X is not compared
+}
+
+// NewScanPattern maps a pattern generated on the combinational core back to
+// the sequential circuit: flip-flop outputs give the load values and a single
+// capture cycle of all domains applies the primary inputs. Expected values
+// come from simulating the fault-free circuit.
+func NewScanPattern(c *circuit.Circuit, pattern map[*circuit.Signal]circuit.SignalValue) (*ScanPattern, error) {
+	sp := &ScanPattern{Load: make(map[*circuit.FlipFlop]circuit.SignalValue)}
+	for _, ff := range c.FlipFlops {
+		sp.Load[ff] = valueIn(pattern, ff.Q)
+	}
+
+	cycle := &CaptureCycle{
+		Inputs:  make(map[*circuit.Signal]circuit.SignalValue),
+		Domains: c.ClockDomains(),
+	}
+	for _, input := range c.PrimaryInputs {
+		cycle.Inputs[input] = valueIn(pattern, input)
+	}
+	sp.Cycles = []*CaptureCycle{cycle}
+
+	if err := sp.Simulate(c); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// Simulate fills in the expected output and unload values of the pattern
+// by simulating the fault-free circuit from the load values. The state and
+// signal values of the circuit are restored afterwards.
+func (sp *ScanPattern) Simulate(c *circuit.Circuit) error {
+	state := c.GetState()
+	values := make(map[*circuit.Signal]circuit.SignalValue, len(c.Signals))
+	for _, signal := range c.Signals {
+		values[signal] = signal.Value
+	}
+	defer func() {
+		c.SetState(state)
+		for signal, value := range values {
+			signal.SetValue(value)
+		}
+	}()
+
+	c.SetState(sp.Load)
+	if sp.Launch != nil && len(sp.Cycles) > 0 {
+		// Flip-flops on no chain capture during the launching shift
+		if _, err := c.Step(sp.Cycles[0].Inputs); err != nil {
+			return err
+		}
+		for _, chain := range c.ScanChains {
+			for _, cell := range chain.Cells {
+				cell.FlipFlop.State = sp.Load[cell.FlipFlop]
+			}
+			chain.Shift(valueIn(sp.Launch, chain))
+		}
+	}
+	for _, cycle := range sp.Cycles {
+		cycle.State = c.GetState()
+		outputs, err := c.Step(cycle.Inputs, cycle.Domains...)
+		if err != nil {
+			return err
+		}
+		cycle.Outputs = outputs
+	}
+	sp.Unload = c.GetState()
+	return nil
+}
+
+// valueIn returns the value of a signal or chain in a pattern, X if unspecified
+func valueIn[K comparable](pattern map[K]circuit.SignalValue, key K) circuit.SignalValue {
+	if value, ok := pattern[key]; ok {
+		return value
+	}
+	return circuit.X
+}
+
+// ScanCycle is one tester cycle of a scan test. A shift cycle applies a value
+// to every scan input and compares every scan output, a capture cycle applies
+// the primary inputs in functional mode and pulses the clocks of its domains.
+type ScanCycle struct {
+	Shift   bool
+	ScanIn  map[*circuit.ScanChain]circuit.SignalValue
+	ScanOut map[*circuit.ScanChain]circuit.SignalValue // Expected values, X is not compared
+	Capture *CaptureCycle
+}
+
+// ScanSequence converts scan patterns into tester cycles. The first pattern is
+// shifted in, then every pattern runs its capture cycles and is shifted out
+// while the next one is shifted in. Chains shorter than the longest one get
+// don't care values first, so every chain is loaded by the last shift.
+func ScanSequence(c *circuit.Circuit, patterns []*ScanPattern) ([]*ScanCycle, error) {
+	for _, sp := range patterns {
+		for ff, value := range sp.Load {
+			if value != circuit.X && c.ScanChainOf(ff) == nil {
+				return nil, fmt.Errorf("flip-flop %s has a load value but is on no scan chain", ff.ID)
+			}
+		}
+	}
+
+	cycles := make([]*ScanCycle, 0)
+	var previous *ScanPattern
+	for _, sp := range patterns {
+		cycles = append(cycles, shiftCycles(c, sp, previous)...)
+		if sp.Launch != nil {
+			cycles = append(cycles, launchCycle(c, sp))
+		}
+		for _, capture := range sp.Cycles {
+			cycles = append(cycles, &ScanCycle{Capture: capture})
+		}
+		previous = sp
+	}
+	if previous != nil {
+		cycles = append(cycles, shiftCycles(c, nil, previous)...)
+	}
+	return cycles, nil
+}
+
+// shiftCycles loads the next pattern while unloading the previous one,
+// either may be nil
+func shiftCycles(c *circuit.Circuit, next, previous *ScanPattern) []*ScanCycle {
+	shifts := 0
+	for _, chain := range c.ScanChains {
+		if chain.Length() > shifts {
+			shifts = chain.Length()
+		}
+	}
+
+	cycles := make([]*ScanCycle, shifts)
+	for t := range cycles {
+		cycle := &ScanCycle{
+			Shift:   true,
+			ScanIn:  make(map[*circuit.ScanChain]circuit.SignalValue),
+			ScanOut: make(map[*circuit.ScanChain]circuit.SignalValue),
+		}
+		for _, chain := range c.ScanChains {
+			length := chain.Length()
+
+			// The bit shifted in at cycle t ends in cell shifts-1-t
+			cycle.ScanIn[chain] = circuit.X
+			if cell := shifts - 1 - t; next != nil && cell < length {
+				cycle.ScanIn[chain] = applyParity(next.Load[chain.Cells[cell].FlipFlop], chain.LoadParity(cell))
+			}
+			// The last cell is seen first
+			cycle.ScanOut[chain] = circuit.X
+			if cell := length - 1 - t; previous != nil && cell >= 0 {
+				cycle.ScanOut[chain] = applyParity(previous.Unload[chain.Cells[cell].FlipFlop], chain.UnloadParity(cell))
+			}
+		}
+		cycles[t] = cycle
+	}
+	return cycles
+}
+
+// launchCycle shifts in the launch values of a launch-on-shift pattern, the
+// values shifted out belong to its own load and are not compared
+func launchCycle(c *circuit.Circuit, sp *ScanPattern) *ScanCycle {
+	cycle := &ScanCycle{
+		Shift:   true,
+		ScanIn:  make(map[*circuit.ScanChain]circuit.SignalValue),
+		ScanOut: make(map[*circuit.ScanChain]circuit.SignalValue),
+	}
+	for _, chain := range c.ScanChains {
+		cycle.ScanIn[chain] = valueIn(sp.Launch, chain)
+		cycle.ScanOut[chain] = circuit.X
+	}
+	return cycle
+}
+
+// FlushSequence returns the chain integrity test: the sequence 0011 repeated
+// over the longest chain plus four cycles is shifted through every chain and
+// expected at the scan outputs once it got through
+func FlushSequence(c *circuit.Circuit) []*ScanCycle {
+	shifts := 0
+	for _, chain := range c.ScanChains {
+		if chain.Length() > shifts {
+			shifts = chain.Length()
+		}
+	}
+
+	flush := []circuit.SignalValue{circuit.ZERO, circuit.ZERO, circuit.ONE, circuit.ONE}
+	cycles := make([]*ScanCycle, shifts+len(flush))
+	for t := range cycles {
+		cycle := &ScanCycle{
+			Shift:   true,
+			ScanIn:  make(map[*circuit.ScanChain]circuit.SignalValue),
+			ScanOut: make(map[*circuit.ScanChain]circuit.SignalValue),
+		}
+		for _, chain := range c.ScanChains {
+			cycle.ScanIn[chain] = flush[t%len(flush)]
+			cycle.ScanOut[chain] = circuit.X
+			if sent := t - chain.Length(); sent >= 0 {
+				cycle.ScanOut[chain] = applyParity(flush[sent%len(flush)], chain.LoadParity(chain.Length()-1) != chain.UnloadParity(chain.Length()-1))
+			}
+		}
+		cycles[t] = cycle
+	}
+	return cycles
+}
+
+// WriteScanSequence writes tester cycles as text, one line per cycle. Shift
+// lines list the scan input and expected scan output values in chain order,
+// capture lines the primary input and expected output values and the clocks.
+func WriteScanSequence(w io.Writer, c *circuit.Circuit, cycles []*ScanCycle) error {
+	out := bufio.NewWriter(w)
+	for _, chain := range c.ScanChains {
+		fmt.Fprintf(out, "# chain %s: %s -> %s, %d cells\n", chain.Name, chain.ScanIn, chain.ScanOut, chain.Length())
+	}
+	for _, cycle := range cycles {
+		if cycle.Shift {
+			var in, expected strings.Builder
+			for _, chain := range c.ScanChains {
+				in.WriteString(valueString(cycle.ScanIn[chain]))
+				expected.WriteString(valueString(cycle.ScanOut[chain]))
+			}
+			fmt.Fprintf(out, "shift   %s %s\n", in.String(), expected.String())
+			continue
+		}
+
+		var in, expected strings.Builder
+		for _, input := range c.PrimaryInputs {
+			in.WriteString(valueString(valueIn(cycle.Capture.Inputs, input)))
+		}
+		for _, output := range c.PrimaryOutputs {
+			expected.WriteString(valueString(valueIn(cycle.Capture.Outputs, output)))
+		}
+		fmt.Fprintf(out, "capture %s %s %s\n", in.String(), expected.String(), strings.Join(cycle.Capture.Domains, ","))
+	}
+	return out.Flush()
+}
+
+// applyParity inverts a value if the parity says so
+func applyParity(value circuit.SignalValue, inverted bool) circuit.SignalValue {
+	if !inverted {
+		return value
+	}
+	switch value {
+	case circuit.ZERO:
+		return circuit.ONE
+	case circuit.ONE:
+		return circuit.ZERO
+	default:
+		return value
+	}
+}
+
+// valueString returns the tester character of a value
+func valueString(value circuit.SignalValue) string {
+	switch value {
+	case circuit.ZERO:
+		return "0"
+	case circuit.ONE:
+		return "1"
+	default:
+		return "X"
+	}
+}
@@ -0,0 +1,113 @@
+// testability.go
+package atpg
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// RandomEngine names the random pattern phase in fault results
+const RandomEngine = "Random"
+
+// FaultTestability is the COP estimate of how well random patterns detect a fault
+type FaultTestability struct {
+	Fault                Fault
+	DetectionProbability float64 // Probability of one random pattern detecting the fault
+	RandomResistant      bool    // The probability is below the report threshold
+}
+
+// EscapeProbability returns the probability of n random patterns all missing the fault
+func (ft *FaultTestability) EscapeProbability(n int) float64 {
+	return math.Pow(1-ft.DetectionProbability, float64(n))
+}
+
+// TestabilityReport holds the COP estimates of a fault list
+type TestabilityReport struct {
+	Faults    []*FaultTestability
+	Threshold float64 // Detection probability below which a fault is random-pattern resistant
+	Resistant int
+}
+
+// AnalyzeTestability estimates the random-pattern detection probability of every fault
+func AnalyzeTestability(c *circuit.Circuit, faults []Fault, threshold float64) *TestabilityReport {
+	cop := c.ComputeCOP()
+	report := &TestabilityReport{
+		Faults:    make([]*FaultTestability, 0, len(faults)),
+		Threshold: threshold,
+	}
+	for _, fault := range faults {
+		probability := cop.DetectionProbability(fault.Site, fault.StuckAt)
+		ft := &FaultTestability{
+			Fault:                fault,
+			DetectionProbability: probability,
+			RandomResistant:      probability < threshold,
+		}
+		if ft.RandomResistant {
+			report.Resistant++
+		}
+		report.Faults = append(report.Faults, ft)
+	}
+	return report
+}
+
+// Write prints the estimate of every fault, resistant faults are marked
+func (r *TestabilityReport) Write(w io.Writer) error {
+	for _, ft := range r.Faults {
+		mark := ""
+		if ft.RandomResistant {
+			mark = " resistant"
+		}
+		if _, err := fmt.Fprintf(w, "%-16s %.6f%s\n", ft.Fault, ft.DetectionProbability, mark); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d of %d faults below %.6f\n", r.Resistant, len(r.Faults), r.Threshold)
+	return err
+}
+
+// resistanceThreshold returns the configured threshold, by default a fault
+// expected to be detected less than once by the random patterns is resistant
+func resistanceThreshold(config *types.TestGenerationConfig) float64 {
+	if config.ResistanceThreshold > 0 {
+		return config.ResistanceThreshold
+	}
+	return 1 / float64(config.RandomPatterns)
+}
+
+// runRandomPatterns fault simulates random patterns and returns the faults
+// they detect. Faults predicted random-pattern resistant are not simulated if
+// the config says so, they are left to the generator.
+func (d *Driver) runRandomPatterns(faults []Fault, report *Report) map[Fault]*FaultResult {
+	coreFaults := make([]Fault, len(faults))
+	for i, fault := range faults {
+		coreFaults[i] = Fault{Site: d.coreSignal(fault.Site), StuckAt: fault.StuckAt}
+	}
+	report.Testability = AnalyzeTestability(d.Core, coreFaults, resistanceThreshold(d.Config))
+	for i, ft := range report.Testability.Faults {
+		ft.Fault = faults[i]
+	}
+	candidates := make([]Fault, 0, len(faults))
+	for _, ft := range report.Testability.Faults {
+		if ft.RandomResistant && d.Config.SkipResistantFaults {
+			report.RandomSkipped++
+			continue
+		}
+		candidates = append(candidates, ft.Fault)
+	}
+
+	detected := make(map[Fault]*FaultResult)
+	fs := NewFaultSimulator(d.Circuit)
+	for fault, result := range fs.RandomPatterns(candidates, d.Config.RandomPatterns, d.Config.RandomPatternSeed) {
+		detected[fault] = &FaultResult{
+			Fault:  fault,
+			Status: DETECTED,
+			Engine: RandomEngine,
+			Result: result,
+		}
+	}
+	return detected
+}
@@ -0,0 +1,197 @@
+// transition.go
+package atpg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/algorithm"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// TransitionType is the direction of a transition fault
+type TransitionType int
+
+const (
+	SLOW_TO_RISE TransitionType = iota // The site rises from 0 to 1 too late
+	SLOW_TO_FALL                       // The site falls from 1 to 0 too late
+)
+
+// TransitionFault delays one transition of a line past the capture edge
+type TransitionFault struct {
+	Site *circuit.Signal
+	Type TransitionType
+}
+
+func (f TransitionFault) String() string {
+	if f.Type == SLOW_TO_RISE {
+		return fmt.Sprintf("%s/str", f.Site.ID)
+	}
+	return fmt.Sprintf("%s/stf", f.Site.ID)
+}
+
+// InitialValue returns the value the transition starts from. The launch
+// pattern sees the late site stuck at this value.
+func (f TransitionFault) InitialValue() circuit.SignalValue {
+	if f.Type == SLOW_TO_RISE {
+		return circuit.ZERO
+	}
+	return circuit.ONE
+}
+
+// AllTransitionFaults returns both transition faults on every signal of the circuit
+func AllTransitionFaults(c *circuit.Circuit) []TransitionFault {
+	faults := make([]TransitionFault, 0, 2*len(c.Signals))
+	for _, signal := range c.Signals {
+		faults = append(faults,
+			TransitionFault{Site: signal, Type: SLOW_TO_RISE},
+			TransitionFault{Site: signal, Type: SLOW_TO_FALL})
+	}
+	return faults
+}
+
+// TransitionResult holds the outcome of test generation for one transition fault
+type TransitionResult struct {
+	Fault  TransitionFault
+	Status FaultStatus
+	Result *types.TestResult
+	Scan   *ScanPattern // Two-pattern scan test of a detected fault
+}
+
+// TransitionReport summarizes transition test generation
+type TransitionReport struct {
+	Results   []*TransitionResult
+	Detected  int
+	Redundant int // Faults proven untestable in the launch mode
+	Aborted   int
+	Duration  time.Duration
+}
+
+// Coverage returns the fraction of faults detected
+func (r *TransitionReport) Coverage() float64 {
+	if len(r.Results) == 0 {
+		return 0
+	}
+	return float64(r.Detected) / float64(len(r.Results))
+}
+
+// RunTransitionFaults generates two-pattern scan tests for transition faults
+// with the launch mode of the config. The first pattern is loaded by scan
+// and sets the site to its initial value, the second one is launched by a
+// capture or by the last shift and detects the late transition. Flip-flops
+// on no scan chain can be neither loaded nor unloaded, in full-scan mode
+// every flip-flop must be on a chain.
+func (d *Driver) RunTransitionFaults(faults []TransitionFault) (*TransitionReport, error) {
+	if d.Config.FullScan {
+		for _, ff := range d.Circuit.FlipFlops {
+			if d.Circuit.ScanChainOf(ff) == nil {
+				return nil, fmt.Errorf("full scan: flip-flop %s is on no scan chain", ff.ID)
+			}
+		}
+	}
+
+	report := &TransitionReport{Results: make([]*TransitionResult, 0, len(faults))}
+	start := time.Now()
+
+	for _, fault := range faults {
+		result, tf := algorithm.TransitionFAN(d.Circuit, fault.Site, fault.InitialValue(), d.Config)
+		transitionResult := &TransitionResult{Fault: fault, Status: classify(result), Result: result}
+		if transitionResult.Status == DETECTED {
+			// Simulation only fails on combinational loops, which the unrolled circuit has none of
+			scan, err := newTransitionPattern(d.Circuit, tf, result.TestPattern, d.Config.LaunchMode)
+			if err == nil {
+				transitionResult.Scan = scan
+			}
+		}
+
+		switch transitionResult.Status {
+		case DETECTED:
+			report.Detected++
+		case REDUNDANT:
+			report.Redundant++
+		case ABORTED:
+			report.Aborted++
+		}
+		report.Results = append(report.Results, transitionResult)
+	}
+
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// newTransitionPattern maps a pattern of the two-frame expansion to a scan
+// test. Launch-on-capture applies both frames as capture cycles,
+// launch-on-shift shifts in the launch values and captures once.
+func newTransitionPattern(c *circuit.Circuit, tf *circuit.TimeFrames, pattern map[*circuit.Signal]circuit.SignalValue,
+	mode types.LaunchMode) (*ScanPattern, error) {
+
+	sp := &ScanPattern{Load: make(map[*circuit.FlipFlop]circuit.SignalValue)}
+	for _, ff := range c.FlipFlops {
+		sp.Load[ff] = valueIn(pattern, tf.InitialState(ff))
+	}
+
+	frames := []int{0, 1}
+	if mode == types.LAUNCH_ON_SHIFT {
+		sp.Launch = make(map[*circuit.ScanChain]circuit.SignalValue)
+		for _, chain := range c.ScanChains {
+			sp.Launch[chain] = valueIn(pattern, tf.ScanInput(chain))
+		}
+		frames = []int{1}
+	}
+	for _, frame := range frames {
+		cycle := &CaptureCycle{
+			Inputs:  make(map[*circuit.Signal]circuit.SignalValue),
+			Domains: c.ClockDomains(),
+		}
+		for _, input := range c.PrimaryInputs {
+			cycle.Inputs[input] = valueIn(pattern, tf.Copy(frame, input))
+		}
+		sp.Cycles = append(sp.Cycles, cycle)
+	}
+
+	if err := sp.Simulate(c); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// DetectsTransition checks if a pair of patterns detects the transition
+// fault: the first sets the site to its initial value and the second detects
+// the site stuck at that value
+func (fs *FaultSimulator) DetectsTransition(initialization, launch map[*circuit.Signal]circuit.SignalValue,
+	fault TransitionFault) bool {
+
+	if fs.simulate(initialization, Fault{})[fs.index[fault.Site]] != fault.InitialValue() {
+		return false
+	}
+	return fs.Detects(launch, Fault{Site: fault.Site, StuckAt: fault.InitialValue()})
+}
+
+// TransitionVectors returns the two patterns a simulated transition test
+// applies to the combinational core: the one before the launch and the one
+// captured at speed after it, each made of primary input and flip-flop values
+func (sp *ScanPattern) TransitionVectors(c *circuit.Circuit) (map[*circuit.Signal]circuit.SignalValue,
+	map[*circuit.Signal]circuit.SignalValue) {
+
+	last := len(sp.Cycles) - 1
+	launch := coreVector(c, sp.Cycles[last].State, sp.Cycles[last].Inputs)
+	if sp.Launch != nil {
+		return coreVector(c, sp.Load, sp.Cycles[last].Inputs), launch
+	}
+	return coreVector(c, sp.Cycles[last-1].State, sp.Cycles[last-1].Inputs), launch
+}
+
+// coreVector joins flip-flop and primary input values into a pattern of the combinational core
+func coreVector(c *circuit.Circuit, state map[*circuit.FlipFlop]circuit.SignalValue,
+	inputs map[*circuit.Signal]circuit.SignalValue) map[*circuit.Signal]circuit.SignalValue {
+
+	vector := make(map[*circuit.Signal]circuit.SignalValue, len(c.FlipFlops)+len(inputs))
+	for _, ff := range c.FlipFlops {
+		vector[ff.Q] = valueIn(state, ff)
+	}
+	for input, value := range inputs {
+		vector[input] = value
+	}
+	return vector
+}
@@ -0,0 +1,194 @@
+// bench.go
+package circuit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadBench parses a circuit in the ISCAS .bench format. AND, OR and NOT map
+// to gates directly, NAND, NOR, BUF, XOR and XNOR are built from them, and
+// DFF lines become flip-flops in the default clock domain.
+func ReadBench(r io.Reader) (*Circuit, error) {
+	c := NewCircuit()
+	signals := make(map[string]*Signal)
+	driven := make(map[string]bool)
+	observed := make(map[string]bool)
+	signal := func(name string) *Signal {
+		if signals[name] == nil {
+			signals[name] = NewSignal(name)
+		}
+		return signals[name]
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+
+		name, function, args, err := parseBenchLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		switch function {
+		case "INPUT":
+			if driven[args[0]] {
+				return nil, fmt.Errorf("line %d: signal %s is driven twice", lineNumber, args[0])
+			}
+			c.AddPrimaryInput(signal(args[0]))
+			driven[args[0]] = true
+			continue
+		case "OUTPUT":
+			if observed[args[0]] {
+				return nil, fmt.Errorf("line %d: output %s is declared twice", lineNumber, args[0])
+			}
+			c.AddPrimaryOutput(signal(args[0]))
+			observed[args[0]] = true
+			continue
+		}
+
+		if driven[name] {
+			return nil, fmt.Errorf("line %d: signal %s is driven twice", lineNumber, name)
+		}
+		driven[name] = true
+		inputs := make([]*Signal, len(args))
+		for i, arg := range args {
+			inputs[i] = signal(arg)
+		}
+		if function == "DFF" {
+			if len(inputs) != 1 {
+				return nil, fmt.Errorf("line %d: DFF takes one input", lineNumber)
+			}
+			c.AddFlipFlop(NewFlipFlop(name, inputs[0], signal(name)))
+			continue
+		}
+		if err := c.addBenchGate(function, inputs, signal(name)); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for name := range signals {
+		if !driven[name] {
+			return nil, fmt.Errorf("signal %s is never driven", name)
+		}
+	}
+	c.IdentifyBoundAndHeadLines()
+	return c, nil
+}
+
+// LoadBench reads a circuit from a .bench file
+func LoadBench(path string) (*Circuit, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadBench(file)
+}
+
+// parseBenchLine splits "INPUT(a)" or "out = FUNC(a, b)" into its parts,
+// the name is empty for INPUT and OUTPUT lines
+func parseBenchLine(line string) (string, string, []string, error) {
+	name := ""
+	if i := strings.Index(line, "="); i >= 0 {
+		name = strings.TrimSpace(line[:i])
+		line = strings.TrimSpace(line[i+1:])
+	}
+
+	start, end := strings.Index(line, "("), strings.LastIndex(line, ")")
+	if start <= 0 || end < start {
+		return "", "", nil, fmt.Errorf("invalid line: %s", line)
+	}
+	function := strings.ToUpper(strings.TrimSpace(line[:start]))
+	args := make([]string, 0)
+	for _, arg := range strings.Split(line[start+1:end], ",") {
+		if arg = strings.TrimSpace(arg); arg != "" {
+			args = append(args, arg)
+		}
+	}
+	if len(args) == 0 {
+		return "", "", nil, fmt.Errorf("%s without inputs", function)
+	}
+
+	isPort := function == "INPUT" || function == "OUTPUT"
+	switch {
+	case isPort && (name != "" || len(args) != 1):
+		return "", "", nil, fmt.Errorf("invalid %s declaration", function)
+	case !isPort && name == "":
+		return "", "", nil, fmt.Errorf("%s without output", function)
+	}
+	return name, function, args, nil
+}
+
+// addBenchGate adds the gates computing a .bench function on the output
+func (c *Circuit) addBenchGate(function string, inputs []*Signal, output *Signal) error {
+	switch function {
+	case "AND":
+		c.connect(AND, inputs, output)
+	case "OR":
+		c.connect(OR, inputs, output)
+	case "NOT":
+		if len(inputs) != 1 {
+			return fmt.Errorf("NOT takes one input")
+		}
+		c.connect(NOT, inputs, output)
+	case "BUF", "BUFF":
+		if len(inputs) != 1 {
+			return fmt.Errorf("%s takes one input", function)
+		}
+		c.connect(AND, inputs, output)
+	case "NAND":
+		c.connect(NOT, []*Signal{c.connect(AND, inputs, c.internal(output, "and"))}, output)
+	case "NOR":
+		c.connect(NOT, []*Signal{c.connect(OR, inputs, c.internal(output, "or"))}, output)
+	case "XOR", "XNOR":
+		if len(inputs) < 2 {
+			return fmt.Errorf("%s takes at least two inputs", function)
+		}
+		// Chain two-input XORs, each one OR(AND(a, NOT b), AND(NOT a, b))
+		result := inputs[0]
+		for i, input := range inputs[1:] {
+			target := c.internal(output, fmt.Sprintf("x%d", i))
+			if i == len(inputs)-2 && function == "XOR" {
+				target = output
+			}
+			notA := c.connect(NOT, []*Signal{result}, c.internal(target, "na"))
+			notB := c.connect(NOT, []*Signal{input}, c.internal(target, "nb"))
+			left := c.connect(AND, []*Signal{result, notB}, c.internal(target, "l"))
+			right := c.connect(AND, []*Signal{notA, input}, c.internal(target, "r"))
+			result = c.connect(OR, []*Signal{left, right}, target)
+		}
+		if function == "XNOR" {
+			c.connect(NOT, []*Signal{result}, output)
+		}
+	default:
+		return fmt.Errorf("unsupported function %s", function)
+	}
+	return nil
+}
+
+// connect adds a gate driving the output and returns the output
+func (c *Circuit) connect(gateType GateType, inputs []*Signal, output *Signal) *Signal {
+	for _, input := range inputs {
+		input.AddFanout(output)
+	}
+	c.AddGate(NewGate(output.ID, gateType, inputs, output, c))
+	return output
+}
+
+// internal creates a line inside a decomposed .bench gate. Its name contains
+// a '#', which starts a comment in .bench files, so no declared name is taken.
+func (c *Circuit) internal(output *Signal, suffix string) *Signal {
+	return NewSignal(output.ID + "#" + suffix)
+}
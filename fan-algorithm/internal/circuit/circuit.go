@@ -8,11 +8,17 @@ import (
 
 // Circuit represents the entire digital circuit
 type Circuit struct {
-	Gates          []*Gate   // All gates in the circuit
-	Signals        []*Signal // All signals in the circuit
-	PrimaryInputs  []*Signal // Primary input signals
-	PrimaryOutputs []*Signal // Primary output signals
-	HeadLines      []*Signal // Head lines in the circuit
+	Gates          []*Gate     // All gates in the circuit
+	Signals        []*Signal   // All signals in the circuit
+	PrimaryInputs  []*Signal   // Primary input signals
+	PrimaryOutputs []*Signal   // Primary output signals
+	HeadLines      []*Signal   // Head lines in the circuit
+	FlipFlops      []*FlipFlop // D flip-flops, empty for a combinational circuit
+	ScanChains     []*ScanChain
+	trail          *Trail             // Assignment trail, created on first use
+	scoapValid     bool               // SCOAP measures are up to date with the structure
+	index          map[string]*Signal // Signals by ID, covers Signals[:indexed]
+	indexed        int
 }
 
 // NewCircuit creates a new empty circuit
@@ -23,21 +29,23 @@ func NewCircuit() *Circuit {
 		PrimaryInputs:  make([]*Signal, 0),
 		PrimaryOutputs: make([]*Signal, 0),
 		HeadLines:      make([]*Signal, 0),
+		FlipFlops:      make([]*FlipFlop, 0),
+		ScanChains:     make([]*ScanChain, 0),
 	}
 }
 
 // AddGate adds a new gate to the circuit
 func (c *Circuit) AddGate(gate *Gate) {
 	c.Gates = append(c.Gates, gate)
+	c.scoapValid = false
+	if gate.Output != nil && gate.Output.FanIn == nil {
+		gate.Output.SetFanIn(gate)
+	}
 
 	// Update signal lists if they're not already included
-	if !c.containsSignal(gate.Output) {
-		c.Signals = append(c.Signals, gate.Output)
-	}
+	c.addSignal(gate.Output)
 	for _, input := range gate.Inputs {
-		if !c.containsSignal(input) {
-			c.Signals = append(c.Signals, input)
-		}
+		c.addSignal(input)
 	}
 }
 
@@ -45,60 +53,80 @@ func (c *Circuit) AddGate(gate *Gate) {
 func (c *Circuit) AddPrimaryInput(signal *Signal) {
 	signal.MarkAsPrimary()
 	c.PrimaryInputs = append(c.PrimaryInputs, signal)
-	if !c.containsSignal(signal) {
-		c.Signals = append(c.Signals, signal)
-	}
+	c.scoapValid = false
+	c.addSignal(signal)
 }
 
 // AddPrimaryOutput adds a new primary output to the circuit
 func (c *Circuit) AddPrimaryOutput(signal *Signal) {
 	signal.MarkAsPrimary()
 	c.PrimaryOutputs = append(c.PrimaryOutputs, signal)
-	if !c.containsSignal(signal) {
-		c.Signals = append(c.Signals, signal)
+	c.scoapValid = false
+	c.addSignal(signal)
+}
+
+// addSignal adds a signal to the signal list if it's not already included
+func (c *Circuit) addSignal(signal *Signal) {
+	if c.containsSignal(signal) {
+		return
 	}
+	c.Signals = append(c.Signals, signal)
+	signal.trail = c.trail
 }
 
 // containsSignal checks if a signal is already in the circuit
 func (c *Circuit) containsSignal(signal *Signal) bool {
-	for _, s := range c.Signals {
-		if s.ID == signal.ID {
-			return true
+	return c.lookup(signal.ID) != nil
+}
+
+// lookup returns the signal with the given ID or nil. Signals appended to
+// c.Signals since the last lookup are indexed first.
+func (c *Circuit) lookup(id string) *Signal {
+	if c.index == nil || c.indexed > len(c.Signals) {
+		c.index = make(map[string]*Signal, len(c.Signals))
+		c.indexed = 0 // The signal list was replaced
+	}
+	for _, signal := range c.Signals[c.indexed:] {
+		if _, ok := c.index[signal.ID]; !ok {
+			c.index[signal.ID] = signal
 		}
 	}
-	return false
+	c.indexed = len(c.Signals)
+	return c.index[id]
 }
 
 // IdentifyBoundAndHeadLines identifies bound and head lines in the circuit
 func (c *Circuit) IdentifyBoundAndHeadLines() {
-	// Reset head lines
+	// Reset previous classification
 	c.HeadLines = make([]*Signal, 0)
+	for _, signal := range c.Signals {
+		signal.IsBound = false
+		signal.IsHead = false
+	}
 
-	// First identify bound lines through fanout points
-	boundLines := make(map[*Signal]bool)
+	// Every line reachable from a fanout point is bound, and so is every line
+	// reachable from an uncontrollable one so that no free region depends on it
 	for _, signal := range c.Signals {
-		if len(signal.Fanouts) > 1 {
-			// Mark all signals reachable from this fanout as bound
+		if signal.Uncontrollable {
+			c.markReachableSignalsAsBound(signal)
+		}
+		if signal.IsFanoutPoint() {
 			for _, fanout := range signal.Fanouts {
-				boundLines[fanout] = true
+				c.markReachableSignalsAsBound(fanout)
 			}
 		}
 	}
 
-	// Then identify head lines - free lines adjacent to bound lines
+	// Head lines are free lines feeding a bound line
 	for _, signal := range c.Signals {
-		if !boundLines[signal] { // If signal is free
-			// Check if it's adjacent to any bound line
-			isHead := false
-			for _, fanout := range signal.Fanouts {
-				if boundLines[fanout] {
-					isHead = true
-					break
-				}
-			}
-			if isHead {
-				signal.IsHead = true
+		if signal.IsBound {
+			continue
+		}
+		for _, fanout := range signal.Fanouts {
+			if fanout.IsBound {
+				signal.MarkAsHead()
 				c.HeadLines = append(c.HeadLines, signal)
+				break
 			}
 		}
 	}
@@ -118,11 +146,12 @@ func (c *Circuit) markReachableSignalsAsBound(signal *Signal) {
 	}
 }
 
-// Simulate performs circuit simulation with current input values
+// Simulate performs circuit simulation with current input values,
+// flip-flop outputs keep their values like primary inputs
 func (c *Circuit) Simulate() error {
-	// Initialize all non-primary-input signals to X
+	// Initialize all gate outputs to X
 	for _, signal := range c.Signals {
-		if !signal.IsPrimary {
+		if signal.FanIn != nil {
 			signal.SetValue(X)
 		}
 	}
@@ -150,10 +179,8 @@ func (c *Circuit) Simulate() error {
 
 // GetSignalByID returns a signal by its ID
 func (c *Circuit) GetSignalByID(id string) (*Signal, error) {
-	for _, signal := range c.Signals {
-		if signal.ID == id {
-			return signal, nil
-		}
+	if signal := c.lookup(id); signal != nil {
+		return signal, nil
 	}
 	return nil, fmt.Errorf("signal with ID %s not found", id)
 }
@@ -202,43 +229,8 @@ func (c *Circuit) ValidateCircuit() error {
 	return nil
 }
 
-// FindMandatoryPaths finds paths that must be sensitized for fault propagation
+// FindMandatoryPaths finds signals that must be sensitized for fault propagation,
+// i.e. the dominators of the signal towards the primary outputs
 func (c *Circuit) FindMandatoryPaths(from *Signal) []*Signal {
-	paths := from.GetPathsToOutputs()
-	if len(paths) == 0 {
-		return nil
-	}
-
-	// Find signals that appear in all paths
-	signalCount := make(map[*Signal]int)
-	for _, path := range paths {
-		seen := make(map[*Signal]bool)
-		for _, signal := range path {
-			if !seen[signal] {
-				signalCount[signal]++
-				seen[signal] = true
-			}
-		}
-	}
-
-	mandatory := make([]*Signal, 0)
-	for signal, count := range signalCount {
-		if count == len(paths) {
-			mandatory = append(mandatory, signal)
-		}
-	}
-	return mandatory
-}
-
-// InitializeControllability sets initial controllability values
-func (c *Circuit) InitializeControllability() {
-	for _, gate := range c.Gates {
-		gate.Controllability = gate.CalculateControllability()
-		gate.Output.Controllability = gate.Controllability
-	}
-
-	// Primary inputs are easiest to control
-	for _, input := range c.PrimaryInputs {
-		input.Controllability = 1
-	}
+	return c.ComputeDominators().Dominators(from)
 }
@@ -0,0 +1,160 @@
+// cop.go
+package circuit
+
+// COP holds the COP testability measures of a circuit: the probability of
+// every signal being 1 and of its value being observed at a primary output,
+// under uniformly random input patterns. Reconvergent fanout is ignored, so
+// both are estimates.
+type COP struct {
+	Probability   map[*Signal]float64 // Probability of the signal being 1
+	Observability map[*Signal]float64 // Probability of a change on the signal reaching an output
+	driven        map[*Signal]float64 // Probability of 1 seen by the fanouts, differs behind a control point
+}
+
+// ComputeCOP computes the COP measures, probabilities from the primary inputs
+// forwards and observabilities from the primary outputs backwards
+func (c *Circuit) ComputeCOP() *COP {
+	return c.computeCOP(c.TopologicalOrder(), nil)
+}
+
+// computeCOP computes the COP measures of the original lines as if the test
+// points were inserted
+func (c *Circuit) computeCOP(order []*Signal, points map[*Signal]TestPointKind) *COP {
+	cop := &COP{
+		Probability:   make(map[*Signal]float64),
+		Observability: make(map[*Signal]float64),
+		driven:        make(map[*Signal]float64),
+	}
+
+	for _, signal := range order {
+		cop.computeProbability(signal, points)
+	}
+
+	isOutput := make(map[*Signal]bool)
+	for _, output := range c.PrimaryOutputs {
+		isOutput[output] = true
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		cop.computeObservability(order[i], points, isOutput)
+	}
+
+	return cop
+}
+
+// computeProbability computes the probability of the signal from its inputs
+func (cop *COP) computeProbability(signal *Signal, points map[*Signal]TestPointKind) {
+	gate := signal.FanIn
+	switch {
+	case gate == nil:
+		cop.Probability[signal] = 0.5
+	case gate.Type == AND:
+		cop.Probability[signal] = cop.allInputs(gate, ONE, nil)
+	case gate.Type == OR:
+		cop.Probability[signal] = 1 - cop.allInputs(gate, ZERO, nil)
+	case gate.Type == NOT:
+		cop.Probability[signal] = 1 - cop.driven[gate.Inputs[0]]
+	}
+
+	// The random control input forces the line half of the time
+	cop.driven[signal] = cop.Probability[signal]
+	if kind, ok := points[signal]; ok {
+		switch kind {
+		case CONTROL_0_POINT:
+			cop.driven[signal] /= 2
+		case CONTROL_1_POINT:
+			cop.driven[signal] = (1 + cop.driven[signal]) / 2
+		}
+	}
+}
+
+// computeObservability computes the observability of the signal from its fanouts
+func (cop *COP) computeObservability(signal *Signal, points map[*Signal]TestPointKind, isOutput map[*Signal]bool) {
+	if isOutput[signal] {
+		cop.Observability[signal] = 1
+		return
+	}
+
+	// Fanout branches are treated as independent paths to the outputs
+	unobserved := 1.0
+	for _, fanout := range signal.Fanouts {
+		gate := fanout.FanIn
+		if gate == nil || !gate.hasInput(signal) {
+			continue
+		}
+		observed := cop.Observability[fanout]
+		if gate.Type == AND || gate.Type == OR {
+			observed *= cop.allInputs(gate, gate.GetNonControllingValue(), signal)
+		}
+		unobserved *= 1 - observed
+	}
+	cop.Observability[signal] = 1 - unobserved
+
+	// A control point passes the line on while its control input is non-controlling
+	if kind, ok := points[signal]; ok {
+		switch kind {
+		case OBSERVATION_POINT:
+			cop.Observability[signal] = 1
+		case CONTROL_0_POINT, CONTROL_1_POINT:
+			cop.Observability[signal] /= 2
+		}
+	}
+}
+
+// copValues are the measures of a signal saved before an update
+type copValues struct {
+	probability, observability, driven float64
+}
+
+// update recomputes the probabilities of the cone and the observabilities of
+// the region, which must hold every line whose measures the points change.
+// The previous values are returned for restore.
+func (cop *COP) update(cone, region []*Signal, points map[*Signal]TestPointKind,
+	isOutput map[*Signal]bool) map[*Signal]copValues {
+
+	saved := make(map[*Signal]copValues, len(region))
+	for _, signal := range region {
+		saved[signal] = copValues{cop.Probability[signal], cop.Observability[signal], cop.driven[signal]}
+	}
+	for _, signal := range cone {
+		cop.computeProbability(signal, points)
+	}
+	for i := len(region) - 1; i >= 0; i-- {
+		cop.computeObservability(region[i], points, isOutput)
+	}
+	return saved
+}
+
+// restore puts back the values saved by update
+func (cop *COP) restore(saved map[*Signal]copValues) {
+	for signal, values := range saved {
+		cop.Probability[signal] = values.probability
+		cop.Observability[signal] = values.observability
+		cop.driven[signal] = values.driven
+	}
+}
+
+// DetectionProbability estimates the probability of a random pattern detecting
+// the stuck-at fault: the site must take the opposite value and be observed
+func (cop *COP) DetectionProbability(site *Signal, stuckAt SignalValue) float64 {
+	excited := cop.Probability[site]
+	if stuckAt == ONE {
+		excited = 1 - excited
+	}
+	return excited * cop.Observability[site]
+}
+
+// allInputs returns the probability of every input except skip having the value
+func (cop *COP) allInputs(gate *Gate, value SignalValue, skip *Signal) float64 {
+	probability := 1.0
+	for _, input := range gate.Inputs {
+		if input == skip {
+			continue
+		}
+		if value == ONE {
+			probability *= cop.driven[input]
+		} else {
+			probability *= 1 - cop.driven[input]
+		}
+	}
+	return probability
+}
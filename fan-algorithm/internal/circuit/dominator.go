@@ -0,0 +1,140 @@
+// dominator.go
+package circuit
+
+// DominatorTree holds the immediate dominator of every signal towards primary outputs.
+// A signal d dominates s if every path from s to a primary output passes through d.
+type DominatorTree struct {
+	Idom map[*Signal]*Signal // Immediate dominator, nil if only the outputs as a whole dominate
+	rank map[*Signal]int     // Distance from the virtual output sink in processing order
+}
+
+// TopologicalOrder returns all signals ordered from primary inputs to primary outputs
+func (c *Circuit) TopologicalOrder() []*Signal {
+	visited := make(map[*Signal]bool)
+	postOrder := make([]*Signal, 0, len(c.Signals))
+
+	var dfs func(*Signal)
+	dfs = func(signal *Signal) {
+		if visited[signal] {
+			return
+		}
+		visited[signal] = true
+		for _, fanout := range signal.Fanouts {
+			dfs(fanout)
+		}
+		postOrder = append(postOrder, signal)
+	}
+	for _, signal := range c.Signals {
+		dfs(signal)
+	}
+
+	order := make([]*Signal, len(postOrder))
+	for i, signal := range postOrder {
+		order[len(postOrder)-1-i] = signal
+	}
+	return order
+}
+
+// ComputeDominators builds the dominator tree of the circuit towards its primary outputs.
+// All primary outputs are joined in a virtual sink, signals are processed in reverse
+// topological order so every fanout is resolved before the signal itself.
+func (c *Circuit) ComputeDominators() *DominatorTree {
+	dt := &DominatorTree{
+		Idom: make(map[*Signal]*Signal),
+		rank: make(map[*Signal]int),
+	}
+
+	isOutput := make(map[*Signal]bool)
+	for _, output := range c.PrimaryOutputs {
+		isOutput[output] = true
+	}
+
+	order := c.TopologicalOrder()
+	next := 1 // Rank 0 is the virtual sink
+	for i := len(order) - 1; i >= 0; i-- {
+		signal := order[i]
+
+		var idom *Signal
+		reachesSink := false
+		first := true
+		if isOutput[signal] {
+			reachesSink = true
+			first = false
+		}
+		for _, fanout := range signal.Fanouts {
+			if _, ok := dt.rank[fanout]; !ok {
+				continue // Fanout does not reach any primary output
+			}
+			if first {
+				idom = fanout
+				first = false
+			} else {
+				idom = dt.intersect(idom, fanout)
+			}
+			reachesSink = true
+		}
+		if !reachesSink {
+			continue
+		}
+
+		dt.Idom[signal] = idom
+		dt.rank[signal] = next
+		next++
+	}
+
+	return dt
+}
+
+// intersect walks both dominator chains up to their nearest common dominator.
+// A nil signal stands for the virtual sink.
+func (dt *DominatorTree) intersect(a, b *Signal) *Signal {
+	for a != b {
+		for a != nil && (b == nil || dt.rank[a] > dt.rank[b]) {
+			a = dt.Idom[a]
+		}
+		for b != nil && (a == nil || dt.rank[b] > dt.rank[a]) {
+			b = dt.Idom[b]
+		}
+	}
+	return a
+}
+
+// Dominators returns the dominators of a signal including itself, nearest first
+func (dt *DominatorTree) Dominators(signal *Signal) []*Signal {
+	if _, ok := dt.rank[signal]; !ok {
+		return nil
+	}
+	dominators := make([]*Signal, 0)
+	for curr := signal; curr != nil; curr = dt.Idom[curr] {
+		dominators = append(dominators, curr)
+	}
+	return dominators
+}
+
+// Dominates checks if d lies on every path from s to a primary output
+func (dt *DominatorTree) Dominates(d, s *Signal) bool {
+	for _, dominator := range dt.Dominators(s) {
+		if dominator == d {
+			return true
+		}
+	}
+	return false
+}
+
+// CommonDominators returns the signals dominating every given signal, nearest first
+func (dt *DominatorTree) CommonDominators(signals []*Signal) []*Signal {
+	if len(signals) == 0 {
+		return nil
+	}
+	for _, signal := range signals {
+		if _, ok := dt.rank[signal]; !ok {
+			return nil
+		}
+	}
+
+	common := signals[0]
+	for _, signal := range signals[1:] {
+		common = dt.intersect(common, signal)
+	}
+	return dt.Dominators(common)
+}
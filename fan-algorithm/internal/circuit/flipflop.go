@@ -0,0 +1,252 @@
+// flipflop.go
+package circuit
+
+import "fmt"
+
+// DefaultClock is the clock domain of flip-flops not given one
+const DefaultClock = "clk"
+
+// FlipFlop is a D flip-flop. Its output Q has no driving gate, so the
+// combinational logic sees it as a pseudo-primary input, and its data input D
+// as a pseudo-primary output.
+type FlipFlop struct {
+	ID         string
+	D          *Signal     // Data input
+	Q          *Signal     // Output
+	Clock      string      // Clock domain, flops of one domain capture together
+	Reset      *Signal     // Active-high reset, nil if the flop has none
+	AsyncReset bool        // The reset acts immediately rather than at the clock edge
+	ResetValue SignalValue // State taken on reset
+	State      SignalValue // Value currently stored
+}
+
+// NewFlipFlop creates a flip-flop in the default clock domain, holding X
+func NewFlipFlop(id string, d, q *Signal) *FlipFlop {
+	return &FlipFlop{
+		ID:         id,
+		D:          d,
+		Q:          q,
+		Clock:      DefaultClock,
+		ResetValue: ZERO,
+		State:      X,
+	}
+}
+
+// AddFlipFlop adds a flip-flop to the circuit
+func (c *Circuit) AddFlipFlop(ff *FlipFlop) {
+	c.FlipFlops = append(c.FlipFlops, ff)
+	c.addSignal(ff.D)
+	c.addSignal(ff.Q)
+	if ff.Reset != nil {
+		c.addSignal(ff.Reset)
+	}
+	c.scoapValid = false
+}
+
+// IsSequential checks if the circuit holds any flip-flop
+func (c *Circuit) IsSequential() bool {
+	return len(c.FlipFlops) > 0
+}
+
+// PseudoPrimaryInputs returns the flip-flop outputs, which drive the
+// combinational logic like primary inputs
+func (c *Circuit) PseudoPrimaryInputs() []*Signal {
+	inputs := make([]*Signal, len(c.FlipFlops))
+	for i, ff := range c.FlipFlops {
+		inputs[i] = ff.Q
+	}
+	return inputs
+}
+
+// PseudoPrimaryOutputs returns the flip-flop data inputs, which capture the
+// combinational logic like primary outputs
+func (c *Circuit) PseudoPrimaryOutputs() []*Signal {
+	outputs := make([]*Signal, len(c.FlipFlops))
+	for i, ff := range c.FlipFlops {
+		outputs[i] = ff.D
+	}
+	return outputs
+}
+
+// ClockDomains returns the clock domains of the flip-flops in order of appearance
+func (c *Circuit) ClockDomains() []string {
+	seen := make(map[string]bool)
+	domains := make([]string, 0)
+	for _, ff := range c.FlipFlops {
+		if !seen[ff.Clock] {
+			seen[ff.Clock] = true
+			domains = append(domains, ff.Clock)
+		}
+	}
+	return domains
+}
+
+// GetFlipFlopByID returns a flip-flop by its ID
+func (c *Circuit) GetFlipFlopByID(id string) (*FlipFlop, error) {
+	for _, ff := range c.FlipFlops {
+		if ff.ID == id {
+			return ff, nil
+		}
+	}
+	return nil, fmt.Errorf("flip-flop with ID %s not found", id)
+}
+
+// SetState loads the state of every flip-flop, flops missing from the map hold X
+func (c *Circuit) SetState(state map[*FlipFlop]SignalValue) {
+	for _, ff := range c.FlipFlops {
+		value, ok := state[ff]
+		if !ok {
+			value = X
+		}
+		ff.State = value
+	}
+}
+
+// GetState returns the state of every flip-flop
+func (c *Circuit) GetState() map[*FlipFlop]SignalValue {
+	state := make(map[*FlipFlop]SignalValue, len(c.FlipFlops))
+	for _, ff := range c.FlipFlops {
+		state[ff] = ff.State
+	}
+	return state
+}
+
+// Step simulates one clock cycle. The primary inputs take the given values and
+// the flip-flops drive their state, the logic settles and the primary output
+// values are returned. Then the flip-flops of the clocked domains, all of them
+// if none is given, capture their data input or their reset value.
+func (c *Circuit) Step(inputs map[*Signal]SignalValue, domains ...string) (map[*Signal]SignalValue, error) {
+	for _, input := range c.PrimaryInputs {
+		value, ok := inputs[input]
+		if !ok {
+			value = X
+		}
+		input.SetValue(value)
+	}
+	if err := c.settle(); err != nil {
+		return nil, err
+	}
+
+	outputs := make(map[*Signal]SignalValue, len(c.PrimaryOutputs))
+	for _, output := range c.PrimaryOutputs {
+		outputs[output] = output.GetValue()
+	}
+
+	clocked := make(map[string]bool)
+	for _, domain := range domains {
+		clocked[domain] = true
+	}
+	next := make([]SignalValue, len(c.FlipFlops))
+	for i, ff := range c.FlipFlops {
+		next[i] = ff.State
+		if len(domains) > 0 && !clocked[ff.Clock] {
+			continue
+		}
+		next[i] = ff.D.GetValue()
+		if ff.Reset != nil && ff.Reset.GetValue() == ONE {
+			next[i] = ff.ResetValue
+		}
+	}
+	for i, ff := range c.FlipFlops {
+		ff.State = next[i]
+	}
+	return outputs, nil
+}
+
+// settle drives the flip-flop outputs from their state and simulates the
+// logic, again after every asynchronous reset it triggers
+func (c *Circuit) settle() error {
+	for {
+		for _, ff := range c.FlipFlops {
+			ff.Q.SetValue(ff.State)
+		}
+		if err := c.Simulate(); err != nil {
+			return err
+		}
+
+		reset := false
+		for _, ff := range c.FlipFlops {
+			if ff.AsyncReset && ff.Reset != nil && ff.Reset.GetValue() == ONE && ff.State != ff.ResetValue {
+				ff.State = ff.ResetValue
+				reset = true
+			}
+		}
+		if !reset {
+			return nil
+		}
+	}
+}
+
+// ScanCore is the circuit seen by full-scan test generation: every flip-flop
+// is cut, its output becomes a primary input and its data input a primary
+// output. The core is built on copies of the lines and gates, so marking the
+// pseudo-primary lines and generating tests on the core leave the circuit as
+// it is.
+type ScanCore struct {
+	Circuit   *Circuit // The combinational core
+	Original  *Circuit
+	copies    map[*Signal]*Signal
+	originals map[*Signal]*Signal
+}
+
+// CombinationalCore builds the combinational core of the circuit
+func (c *Circuit) CombinationalCore() *ScanCore {
+	sc := &ScanCore{
+		Circuit:   NewCircuit(),
+		Original:  c,
+		copies:    make(map[*Signal]*Signal, len(c.Signals)),
+		originals: make(map[*Signal]*Signal, len(c.Signals)),
+	}
+	core := sc.Circuit
+
+	for _, signal := range c.Signals {
+		line := NewSignal(signal.ID)
+		line.Uncontrollable = signal.Uncontrollable
+		sc.copies[signal] = line
+		sc.originals[line] = signal
+		core.Signals = append(core.Signals, line)
+	}
+	for _, signal := range c.Signals {
+		for _, fanout := range signal.Fanouts {
+			sc.copies[signal].AddFanout(sc.copies[fanout])
+		}
+	}
+	for _, gate := range c.Gates {
+		inputs := make([]*Signal, len(gate.Inputs))
+		for i, input := range gate.Inputs {
+			inputs[i] = sc.copies[input]
+		}
+		copied := NewGate(gate.ID, gate.Type, inputs, sc.copies[gate.Output], core)
+		copied.Delay = gate.Delay
+		copied.Output.SetFanIn(copied)
+		core.Gates = append(core.Gates, copied)
+	}
+
+	added := make(map[*Signal]bool)
+	for _, input := range append(append([]*Signal{}, c.PrimaryInputs...), c.PseudoPrimaryInputs()...) {
+		if !added[input] {
+			added[input] = true
+			core.AddPrimaryInput(sc.copies[input])
+		}
+	}
+	added = make(map[*Signal]bool)
+	for _, output := range append(append([]*Signal{}, c.PrimaryOutputs...), c.PseudoPrimaryOutputs()...) {
+		if !added[output] {
+			added[output] = true
+			core.AddPrimaryOutput(sc.copies[output])
+		}
+	}
+
+	core.IdentifyBoundAndHeadLines()
+	return sc
+}
+
+// Copy returns the copy of a line of the circuit in the core
+func (sc *ScanCore) Copy(signal *Signal) *Signal {
+	return sc.copies[signal]
+}
+
+// OriginalOf returns the line of the circuit a line of the core copies
+func (sc *ScanCore) OriginalOf(signal *Signal) *Signal {
+	return sc.originals[signal]
+}
@@ -12,12 +12,12 @@ const (
 
 // Gate represents a logic gate in the circuit
 type Gate struct {
-	ID              string    // Unique identifier for the gate
-	Type            GateType  // Type of the gate (AND, OR, NOT)
-	Inputs          []*Signal // Input signals
-	Output          *Signal   // Output signal
-	Controllability int       // Controllability metric for the gate
-	Circuit         *Circuit
+	ID      string    // Unique identifier for the gate
+	Type    GateType  // Type of the gate (AND, OR, NOT)
+	Inputs  []*Signal // Input signals
+	Output  *Signal   // Output signal
+	Delay   int       // Annotated propagation delay, zero for a unit delay
+	Circuit *Circuit
 }
 
 // NewGate creates a new gate with the specified type and signals
@@ -41,6 +41,17 @@ func (g *Gate) Evaluate() bool {
 	return oldValue != g.Output.GetValue()
 }
 
+// Imply evaluates the gate like Evaluate but records the output on the trail
+func (g *Gate) Imply() bool {
+	oldValue := g.Output.GetValue()
+
+	if !g.Output.IsFault {
+		g.Output.Assign(g.evaluate())
+	}
+
+	return oldValue != g.Output.GetValue()
+}
+
 func (g *Gate) evaluate() SignalValue {
 	switch g.Type {
 	case AND:
@@ -142,6 +153,14 @@ func evaluateNOT(input *Signal) SignalValue {
 	}
 }
 
+// PropagationDelay returns the annotated delay of the gate, one if it has none
+func (g *Gate) PropagationDelay() int {
+	if g.Delay > 0 {
+		return g.Delay
+	}
+	return 1
+}
+
 // IsControllingValue checks if the given value is a controlling value for the gate
 func (g *Gate) IsControllingValue(value SignalValue) bool {
 	switch g.Type {
@@ -166,33 +185,38 @@ func (g *Gate) GetNonControllingValue() SignalValue {
 	}
 }
 
-// CalculateControllability computes how difficult it is to control this gate
-func (g *Gate) CalculateControllability() int {
-	switch g.Type {
-	case AND:
-		// AND gate is harder to control as number of inputs increases
-		return len(g.Inputs) * 2
-	case OR:
-		// OR gate is harder to control as number of inputs increases
-		return len(g.Inputs) * 2
-	case NOT:
-		return 1
-	default:
-		return 0
+// GetEasiestControllingInput returns the unassigned input with the lowest
+// SCOAP controllability of the controlling value
+func (g *Gate) GetEasiestControllingInput() *Signal {
+	value := ZERO
+	if g.Type == OR {
+		value = ONE
 	}
+	return g.selectInput(value, false)
 }
 
-// GetEasiestControllingInput returns the input that's easiest to control
-func (g *Gate) GetEasiestControllingInput() *Signal {
-	var easiest *Signal
-	minControl := int(^uint(0) >> 1)
+// GetHardestNonControllingInput returns the unassigned input with the highest
+// SCOAP controllability of the non-controlling value
+func (g *Gate) GetHardestNonControllingInput() *Signal {
+	return g.selectInput(g.GetNonControllingValue(), true)
+}
 
+func (g *Gate) selectInput(value SignalValue, hardest bool) *Signal {
+	if g.Circuit != nil {
+		g.Circuit.ensureSCOAP()
+	}
+
+	var selected *Signal
+	best := 0
 	for _, input := range g.Inputs {
-		control := len(input.GetReachableFanouts())
-		if control < minControl {
-			minControl = control
-			easiest = input
+		if input.GetValue() != X {
+			continue
+		}
+		cost := input.Controllability(value)
+		if selected == nil || (hardest && cost > best) || (!hardest && cost < best) {
+			selected = input
+			best = cost
 		}
 	}
-	return easiest
+	return selected
 }
@@ -0,0 +1,78 @@
+// region.go
+package circuit
+
+// FreeRegion represents a fanout-free subcircuit rooted at a head line
+type FreeRegion struct {
+	Head          *Signal   // Head line at the root of the region
+	Lines         []*Signal // All lines in the region, head line first
+	PrimaryInputs []*Signal // Primary inputs at the leaves of the region
+}
+
+// IsFree checks if the signal is a free line (not reachable from any fanout point)
+func (s *Signal) IsFree() bool {
+	return !s.IsBound
+}
+
+// Contains checks if the signal belongs to this free region
+func (r *FreeRegion) Contains(signal *Signal) bool {
+	for _, line := range r.Lines {
+		if line == signal {
+			return true
+		}
+	}
+	return false
+}
+
+// FreeRegions returns the free region rooted at every head line.
+// IdentifyBoundAndHeadLines must have been called beforehand.
+func (c *Circuit) FreeRegions() []*FreeRegion {
+	regions := make([]*FreeRegion, 0, len(c.HeadLines))
+	for _, head := range c.HeadLines {
+		regions = append(regions, c.FreeRegionOf(head))
+	}
+	return regions
+}
+
+// FreeRegionOf collects the free region rooted at the given head line.
+// Since the region is fanout-free, it is a tree that can be walked backward.
+func (c *Circuit) FreeRegionOf(head *Signal) *FreeRegion {
+	region := &FreeRegion{
+		Head:          head,
+		Lines:         make([]*Signal, 0),
+		PrimaryInputs: make([]*Signal, 0),
+	}
+
+	var walk func(*Signal)
+	walk = func(signal *Signal) {
+		region.Lines = append(region.Lines, signal)
+		if signal.FanIn == nil {
+			region.PrimaryInputs = append(region.PrimaryInputs, signal)
+			return
+		}
+		for _, input := range signal.FanIn.Inputs {
+			if input.IsFree() {
+				walk(input)
+			}
+		}
+	}
+	walk(head)
+
+	return region
+}
+
+// HeadLineOf returns the head line whose free region contains the signal.
+// Returns nil for bound lines and for free lines that only reach primary outputs.
+func (c *Circuit) HeadLineOf(signal *Signal) *Signal {
+	curr := signal
+	for curr != nil && curr.IsFree() {
+		if curr.IsHead {
+			return curr
+		}
+		// A free line that is not a head line has at most one fanout
+		if len(curr.Fanouts) != 1 {
+			return nil
+		}
+		curr = curr.Fanouts[0]
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// scan.go
+package circuit
+
+import "fmt"
+
+// ScanCell is a flip-flop on a scan chain
+type ScanCell struct {
+	FlipFlop *FlipFlop
+	Inverted bool // An inverter sits between the previous cell, or the scan input, and this cell
+}
+
+// ScanChain is an ordered list of flip-flops shifted from the scan input
+// towards the scan output, the first cell is next to the scan input
+type ScanChain struct {
+	Name           string
+	ScanIn         string // Name of the scan input port
+	ScanOut        string // Name of the scan output port
+	Cells          []ScanCell
+	OutputInverted bool // An inverter sits between the last cell and the scan output
+}
+
+// NewScanChain creates a chain of the flip-flops without inversions
+func NewScanChain(name, scanIn, scanOut string, flops ...*FlipFlop) *ScanChain {
+	chain := &ScanChain{Name: name, ScanIn: scanIn, ScanOut: scanOut}
+	for _, ff := range flops {
+		chain.Cells = append(chain.Cells, ScanCell{FlipFlop: ff})
+	}
+	return chain
+}
+
+// Length returns the number of cells of the chain
+func (sc *ScanChain) Length() int {
+	return len(sc.Cells)
+}
+
+// AddScanChain adds a scan chain, every flip-flop must belong to the circuit
+// and to no other chain
+func (c *Circuit) AddScanChain(chain *ScanChain) error {
+	inCircuit := make(map[*FlipFlop]bool)
+	for _, ff := range c.FlipFlops {
+		inCircuit[ff] = true
+	}
+	for _, cell := range chain.Cells {
+		if !inCircuit[cell.FlipFlop] {
+			return fmt.Errorf("flip-flop %s of chain %s is not in the circuit", cell.FlipFlop.ID, chain.Name)
+		}
+		if other := c.ScanChainOf(cell.FlipFlop); other != nil {
+			return fmt.Errorf("flip-flop %s is already on chain %s", cell.FlipFlop.ID, other.Name)
+		}
+	}
+	c.ScanChains = append(c.ScanChains, chain)
+	return nil
+}
+
+// StitchScanChains puts every flip-flop not yet on a chain onto up to n new
+// chains of balanced length, keeping the order of the flip-flops
+func (c *Circuit) StitchScanChains(n int) []*ScanChain {
+	free := make([]*FlipFlop, 0)
+	for _, ff := range c.FlipFlops {
+		if c.ScanChainOf(ff) == nil {
+			free = append(free, ff)
+		}
+	}
+	if n > len(free) {
+		n = len(free)
+	}
+
+	chains := make([]*ScanChain, 0, n)
+	for i := 0; i < n; i++ {
+		start, end := i*len(free)/n, (i+1)*len(free)/n
+		index := len(c.ScanChains)
+		chain := NewScanChain(fmt.Sprintf("chain%d", index), fmt.Sprintf("si%d", index), fmt.Sprintf("so%d", index), free[start:end]...)
+		c.ScanChains = append(c.ScanChains, chain)
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+// ScanChainOf returns the chain holding the flip-flop, nil if it is not scanned
+func (c *Circuit) ScanChainOf(ff *FlipFlop) *ScanChain {
+	for _, chain := range c.ScanChains {
+		for _, cell := range chain.Cells {
+			if cell.FlipFlop == ff {
+				return chain
+			}
+		}
+	}
+	return nil
+}
+
+// Shift clocks the chain once in shift mode: every cell takes the value of
+// the previous one and the first cell the scan input. Returns the scan output
+// value seen before the clock.
+func (sc *ScanChain) Shift(scanIn SignalValue) SignalValue {
+	if len(sc.Cells) == 0 {
+		return scanIn
+	}
+	scanOut := sc.Cells[len(sc.Cells)-1].FlipFlop.State
+	if sc.OutputInverted {
+		scanOut = invert(scanOut)
+	}
+	for i := len(sc.Cells) - 1; i >= 0; i-- {
+		value := scanIn
+		if i > 0 {
+			value = sc.Cells[i-1].FlipFlop.State
+		}
+		if sc.Cells[i].Inverted {
+			value = invert(value)
+		}
+		sc.Cells[i].FlipFlop.State = value
+	}
+	return scanOut
+}
+
+// LoadParity returns whether a value reaches the cell inverted when shifted in
+func (sc *ScanChain) LoadParity(cell int) bool {
+	inverted := false
+	for i := 0; i <= cell; i++ {
+		inverted = inverted != sc.Cells[i].Inverted
+	}
+	return inverted
+}
+
+// UnloadParity returns whether the value of the cell reaches the scan output inverted
+func (sc *ScanChain) UnloadParity(cell int) bool {
+	inverted := sc.OutputInverted
+	for i := cell + 1; i < len(sc.Cells); i++ {
+		inverted = inverted != sc.Cells[i].Inverted
+	}
+	return inverted
+}
+
+// invert complements a binary value, X stays X
+func invert(value SignalValue) SignalValue {
+	switch value {
+	case ZERO:
+		return ONE
+	case ONE:
+		return ZERO
+	default:
+		return value
+	}
+}
@@ -0,0 +1,125 @@
+// scoap.go
+package circuit
+
+// SCOAPInfinity is the SCOAP measure of a line that can't be controlled or observed
+const SCOAPInfinity = 1 << 30
+
+// ComputeSCOAP computes the SCOAP combinational controllabilities CC0 and CC1
+// and the observability CO of every signal. Controllabilities are computed from
+// the primary inputs forwards, observabilities from the primary outputs backwards.
+func (c *Circuit) ComputeSCOAP() {
+	order := c.TopologicalOrder()
+
+	for _, signal := range order {
+		gate := signal.FanIn
+		if gate == nil {
+			signal.CC0, signal.CC1 = 1, 1
+			if signal.Uncontrollable {
+				signal.CC0, signal.CC1 = SCOAPInfinity, SCOAPInfinity
+			}
+			continue
+		}
+
+		switch gate.Type {
+		case AND:
+			signal.CC0 = addCost(minInputCost(gate.Inputs, ZERO), 1)
+			signal.CC1 = addCost(sumInputCost(gate.Inputs, ONE, nil), 1)
+		case OR:
+			signal.CC0 = addCost(sumInputCost(gate.Inputs, ZERO, nil), 1)
+			signal.CC1 = addCost(minInputCost(gate.Inputs, ONE), 1)
+		case NOT:
+			signal.CC0 = addCost(gate.Inputs[0].CC1, 1)
+			signal.CC1 = addCost(gate.Inputs[0].CC0, 1)
+		default:
+			signal.CC0, signal.CC1 = SCOAPInfinity, SCOAPInfinity
+		}
+	}
+
+	isOutput := make(map[*Signal]bool)
+	for _, output := range c.PrimaryOutputs {
+		isOutput[output] = true
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		signal := order[i]
+		if isOutput[signal] {
+			signal.CO = 0
+			continue
+		}
+
+		// Observe through the easiest fanout, the other gate inputs must be non-controlling
+		signal.CO = SCOAPInfinity
+		for _, fanout := range signal.Fanouts {
+			gate := fanout.FanIn
+			if gate == nil || !gate.hasInput(signal) {
+				continue
+			}
+			cost := addCost(fanout.CO, 1)
+			if gate.Type == AND || gate.Type == OR {
+				cost = addCost(cost, sumInputCost(gate.Inputs, gate.GetNonControllingValue(), signal))
+			}
+			if cost < signal.CO {
+				signal.CO = cost
+			}
+		}
+	}
+
+	c.scoapValid = true
+}
+
+// ensureSCOAP computes the SCOAP measures unless they are up to date
+func (c *Circuit) ensureSCOAP() {
+	if !c.scoapValid {
+		c.ComputeSCOAP()
+	}
+}
+
+// Controllability returns the SCOAP controllability of setting the signal to the value
+func (s *Signal) Controllability(value SignalValue) int {
+	switch value {
+	case ZERO, D_BAR:
+		return s.CC0
+	case ONE, D:
+		return s.CC1
+	default:
+		return 0
+	}
+}
+
+// sumInputCost returns the cost of setting every input except skip to the value
+func sumInputCost(inputs []*Signal, value SignalValue, skip *Signal) int {
+	sum := 0
+	for _, input := range inputs {
+		if input != skip {
+			sum = addCost(sum, input.Controllability(value))
+		}
+	}
+	return sum
+}
+
+// minInputCost returns the cost of setting the easiest input to the value
+func minInputCost(inputs []*Signal, value SignalValue) int {
+	min := SCOAPInfinity
+	for _, input := range inputs {
+		if cost := input.Controllability(value); cost < min {
+			min = cost
+		}
+	}
+	return min
+}
+
+// addCost adds SCOAP measures, saturating at SCOAPInfinity
+func addCost(a, b int) int {
+	if a+b > SCOAPInfinity {
+		return SCOAPInfinity
+	}
+	return a + b
+}
+
+func (g *Gate) hasInput(signal *Signal) bool {
+	for _, input := range g.Inputs {
+		if input == signal {
+			return true
+		}
+	}
+	return false
+}
@@ -25,13 +25,17 @@ type Signal struct {
 	IsBound          bool        // True if the signal is reachable from some fanout point
 	IsHead           bool        // True if it's a free line adjacent to a bound line
 	IsPrimary        bool        // True if it's a primary input/output
+	Uncontrollable   bool        // True if no input can set it, like the unknown initial state of a flip-flop
 	Fanouts          []*Signal   // List of signals this signal fans out to
 	FanIn            *Gate       // Gate that drives this signal (nil for primary inputs)
 	ControllingValue SignalValue // The controlling value for its fanin gate
-	Controllability  int         // Controllability metric
+	CC0              int         // SCOAP 0-controllability
+	CC1              int         // SCOAP 1-controllability
+	CO               int         // SCOAP observability
 	IsFault          bool
 	FaultType        SignalValue
 	Value            SignalValue
+	trail            *Trail // Trail of the circuit recording assignments
 }
 
 // NewSignal creates a new signal with default values
@@ -47,10 +51,12 @@ func NewSignal(id string) *Signal {
 		IsPrimary: false,
 		Fanouts:   make([]*Signal, 0),
 		FanIn:     nil,
+		Value:     X,
 	}
 }
 
-// SetValue sets the value of the signal and marks it as stable
+// SetValue sets the value of the signal for simulation, unlike Assign it is
+// not recorded on the trail. Faulty signals keep their value.
 func (s *Signal) SetValue(v SignalValue) bool {
 	if s.IsFault {
 		return true // Keep faulty value
@@ -75,7 +81,7 @@ func (s *Signal) GetValue() SignalValue {
 
 // IsUnknown checks if the signal value is unknown (X)
 func (s *Signal) IsUnknown() bool {
-	return s.State.Value == X
+	return s.GetValue() == X
 }
 
 // IsFaulty checks if the signal carries a fault value (D or D')
@@ -0,0 +1,196 @@
+// testpoint.go
+package circuit
+
+import "sort"
+
+// TestPointKind is the kind of a test point
+type TestPointKind int
+
+const (
+	OBSERVATION_POINT TestPointKind = iota // The line drives a new primary output
+	CONTROL_0_POINT                        // The line is ANDed with a new primary input, which can force it to 0
+	CONTROL_1_POINT                        // The line is ORed with a new primary input, which can force it to 1
+)
+
+// TestPoint is a test point proposed on a line
+type TestPoint struct {
+	Signal *Signal
+	Kind   TestPointKind
+}
+
+// RecommendTestPoints proposes up to maxPoints test points for the faults
+// whose COP detection probability is below the threshold. Points are chosen
+// greedily, each one bringing the detection probabilities of all faults
+// closest to the threshold given the points chosen before. A point changes
+// the probabilities of its fanout cone and the observabilities of the fanin
+// cone of those lines only, candidates are scored on that region alone.
+func (c *Circuit) RecommendTestPoints(threshold float64, maxPoints int) []TestPoint {
+	order := c.TopologicalOrder()
+	position := make(map[*Signal]int, len(order))
+	for i, signal := range order {
+		position[signal] = i
+	}
+	isOutput := make(map[*Signal]bool)
+	for _, output := range c.PrimaryOutputs {
+		isOutput[output] = true
+	}
+
+	// Faults count up to the threshold, resistant faults are the only ones left to improve
+	score := func(cop *COP, signals []*Signal) (float64, int) {
+		total, resistant := 0.0, 0
+		for _, signal := range signals {
+			for _, stuckAt := range []SignalValue{ZERO, ONE} {
+				probability := cop.DetectionProbability(signal, stuckAt)
+				if probability < threshold {
+					total += probability / threshold
+					resistant++
+				} else {
+					total++
+				}
+			}
+		}
+		return total, resistant
+	}
+
+	points := make([]TestPoint, 0)
+	chosen := make(map[*Signal]TestPointKind)
+	for len(points) < maxPoints {
+		cop := c.computeCOP(order, chosen)
+		if _, resistant := score(cop, order); resistant == 0 {
+			break
+		}
+
+		var best *TestPoint
+		bestGain := 0.0
+		for _, signal := range order {
+			// Lines already carrying a point and primary outputs, observed and driving nothing, are skipped
+			if _, ok := chosen[signal]; ok || isOutput[signal] {
+				continue
+			}
+			cone, region := pointRegion(signal, position)
+			before, _ := score(cop, region)
+			for _, kind := range []TestPointKind{OBSERVATION_POINT, CONTROL_0_POINT, CONTROL_1_POINT} {
+				chosen[signal] = kind
+				saved := cop.update(cone, region, chosen, isOutput)
+				after, _ := score(cop, region)
+				cop.restore(saved)
+				delete(chosen, signal)
+				if gain := after - before; gain > bestGain {
+					best = &TestPoint{Signal: signal, Kind: kind}
+					bestGain = gain
+				}
+			}
+		}
+		if best == nil {
+			break
+		}
+
+		chosen[best.Signal] = best.Kind
+		points = append(points, *best)
+	}
+	return points
+}
+
+// pointRegion returns the fanout cone of a test point line and the fanin
+// cone of that, both in topological order
+func pointRegion(line *Signal, position map[*Signal]int) ([]*Signal, []*Signal) {
+	inCone := map[*Signal]bool{line: true}
+	cone := []*Signal{line}
+	for i := 0; i < len(cone); i++ {
+		for _, fanout := range cone[i].Fanouts {
+			if fanout.FanIn != nil && fanout.FanIn.hasInput(cone[i]) && !inCone[fanout] {
+				inCone[fanout] = true
+				cone = append(cone, fanout)
+			}
+		}
+	}
+
+	inRegion := make(map[*Signal]bool, len(cone))
+	region := make([]*Signal, 0, len(cone))
+	for _, signal := range cone {
+		inRegion[signal] = true
+		region = append(region, signal)
+	}
+	for i := 0; i < len(region); i++ {
+		if region[i].FanIn == nil {
+			continue
+		}
+		for _, input := range region[i].FanIn.Inputs {
+			if !inRegion[input] {
+				inRegion[input] = true
+				region = append(region, input)
+			}
+		}
+	}
+
+	byPosition := func(signals []*Signal) {
+		sort.Slice(signals, func(i, j int) bool { return position[signals[i]] < position[signals[j]] })
+	}
+	byPosition(cone)
+	byPosition(region)
+	return cone, region
+}
+
+// InsertTestPoints adds the test points to the circuit and returns the
+// primary inputs and outputs created. A control point takes over the fanouts
+// of its line, and the primary output if the line was one. Its control input
+// must be 1 for a control-0 point and 0 for a control-1 point to leave the
+// function of the circuit unchanged.
+func (c *Circuit) InsertTestPoints(points []TestPoint) []*Signal {
+	added := make([]*Signal, 0, len(points))
+	for _, point := range points {
+		line := point.Signal
+		if point.Kind == OBSERVATION_POINT {
+			observed := NewSignal(c.freshID(line.ID + "_op"))
+			line.AddFanout(observed)
+			c.AddGate(NewGate("g_"+observed.ID, AND, []*Signal{line}, observed, c))
+			c.AddPrimaryOutput(observed)
+			added = append(added, observed)
+			continue
+		}
+
+		control := NewSignal(c.freshID(line.ID + "_cp"))
+		c.AddPrimaryInput(control)
+		driven := NewSignal(c.freshID(line.ID + "_tp"))
+
+		// Move the fanouts of the line behind the test point
+		driven.Fanouts = line.Fanouts
+		for _, fanout := range driven.Fanouts {
+			for i, input := range fanout.FanIn.Inputs {
+				if input == line {
+					fanout.FanIn.Inputs[i] = driven
+				}
+			}
+		}
+		line.Fanouts = []*Signal{driven}
+		control.AddFanout(driven)
+
+		gateType := AND
+		if point.Kind == CONTROL_1_POINT {
+			gateType = OR
+		}
+		c.AddGate(NewGate("g_"+driven.ID, gateType, []*Signal{line, control}, driven, c))
+
+		for i, output := range c.PrimaryOutputs {
+			if output == line {
+				c.PrimaryOutputs[i] = driven
+				driven.MarkAsPrimary()
+				line.IsPrimary = line.FanIn == nil
+			}
+		}
+		added = append(added, control)
+	}
+
+	c.IdentifyBoundAndHeadLines()
+	return added
+}
+
+// freshID returns an ID based on the given one not used by any signal
+func (c *Circuit) freshID(id string) string {
+	for {
+		if _, err := c.GetSignalByID(id); err != nil {
+			return id
+		}
+		id += "'"
+	}
+}
@@ -0,0 +1,212 @@
+// timeframe.go
+package circuit
+
+import "fmt"
+
+// TimeFrames is a sequential circuit unrolled over clock cycles into one
+// combinational circuit, the iterative logic array of sequential test
+// generation. Frame f holds a copy of every line named <id>@<f>, its
+// flip-flop outputs are driven by the next state of frame f-1. In frame 0
+// they are driven by the initial state, which is unknown and uncontrollable
+// for non-scan flip-flops and a primary input for scanned ones.
+type TimeFrames struct {
+	Circuit  *Circuit // The unrolled combinational circuit
+	Original *Circuit
+	copies   []map[*Signal]*Signal
+	initial  map[*FlipFlop]*Signal
+	scanIn   map[*ScanChain]*Signal // Scan inputs of the launching shift
+	steady   [2]map[*Signal]*Signal // Lines that are 1 when a line is steady at 0 or 1, see Steady
+}
+
+// Unroll builds the time-frame expansion of the circuit over the given number
+// of frames. The primary outputs of every frame are primary outputs, and so
+// are the data inputs of scanned flip-flops in the last frame, which are
+// unloaded after the test. Resets are modelled by gates in front of the
+// state, an asynchronous reset must not depend on the flip-flops.
+func (c *Circuit) Unroll(frames int) *TimeFrames {
+	return c.unroll(frames, false)
+}
+
+// UnrollLaunchOnShift builds the two frames of a launch-on-shift test. The
+// scanned flip-flops of the second frame hold the values of one more shift
+// of the first frame's state instead of the captured ones, the primary inputs
+// keep their values and the scan inputs of the shift are primary inputs.
+func (c *Circuit) UnrollLaunchOnShift() *TimeFrames {
+	return c.unroll(2, true)
+}
+
+func (c *Circuit) unroll(frames int, launchOnShift bool) *TimeFrames {
+	tf := &TimeFrames{
+		Circuit:  NewCircuit(),
+		Original: c,
+		copies:   make([]map[*Signal]*Signal, frames),
+		initial:  make(map[*FlipFlop]*Signal, len(c.FlipFlops)),
+		scanIn:   make(map[*ScanChain]*Signal),
+	}
+	unrolled := tf.Circuit
+
+	next := make([]*Signal, len(c.FlipFlops))
+	for frame := 0; frame < frames; frame++ {
+		copies := make(map[*Signal]*Signal)
+		tf.copies[frame] = copies
+		signal := func(s *Signal) *Signal {
+			if copies[s] == nil {
+				copies[s] = NewSignal(fmt.Sprintf("%s@%d", s.ID, frame))
+			}
+			return copies[s]
+		}
+
+		for _, input := range c.PrimaryInputs {
+			if launchOnShift && frame > 0 {
+				copies[input] = tf.copies[0][input]
+				continue
+			}
+			unrolled.AddPrimaryInput(signal(input))
+		}
+
+		// The state is buffered onto the flip-flop output, so faults on the output stay apart from the previous frame
+		for i, ff := range c.FlipFlops {
+			state := next[i]
+			if frame == 0 {
+				state = NewSignal(ff.ID + "@init")
+				if c.ScanChainOf(ff) != nil {
+					unrolled.AddPrimaryInput(state)
+				} else {
+					state.Uncontrollable = true
+					unrolled.addSignal(state)
+				}
+				tf.initial[ff] = state
+			}
+			if ff.AsyncReset && ff.Reset != nil {
+				state = unrolled.applyReset(ff, state, signal(ff.Reset), fmt.Sprintf("%s_async@%d", ff.ID, frame))
+			}
+			unrolled.connect(AND, []*Signal{state}, signal(ff.Q))
+		}
+
+		for _, gate := range c.Gates {
+			inputs := make([]*Signal, len(gate.Inputs))
+			for i, input := range gate.Inputs {
+				inputs[i] = signal(input)
+			}
+			unrolled.connect(gate.Type, inputs, signal(gate.Output))
+		}
+
+		for _, output := range c.PrimaryOutputs {
+			unrolled.AddPrimaryOutput(signal(output))
+		}
+		for i, ff := range c.FlipFlops {
+			next[i] = signal(ff.D)
+			if ff.Reset != nil {
+				next[i] = unrolled.applyReset(ff, next[i], signal(ff.Reset), fmt.Sprintf("%s_next@%d", ff.ID, frame))
+			}
+			if frame == frames-1 && c.ScanChainOf(ff) != nil && !next[i].IsPrimary {
+				unrolled.AddPrimaryOutput(next[i])
+			}
+		}
+		if launchOnShift && frame == 0 {
+			tf.shift(next, signal)
+		}
+	}
+
+	unrolled.IdentifyBoundAndHeadLines()
+	return tf
+}
+
+// applyReset adds the gates forcing the reset value of the flip-flop onto the
+// line while the reset is active, and returns their output
+func (c *Circuit) applyReset(ff *FlipFlop, line, reset *Signal, id string) *Signal {
+	if ff.ResetValue == ONE {
+		return c.connect(OR, []*Signal{line, reset}, NewSignal(id))
+	}
+	notReset := c.connect(NOT, []*Signal{reset}, NewSignal(id+"_n"))
+	return c.connect(AND, []*Signal{line, notReset}, NewSignal(id))
+}
+
+// shift replaces the next state of the scanned flip-flops by the values one
+// shift moves into them
+func (tf *TimeFrames) shift(next []*Signal, signal func(*Signal) *Signal) {
+	c := tf.Original
+	index := make(map[*FlipFlop]int, len(c.FlipFlops))
+	for i, ff := range c.FlipFlops {
+		index[ff] = i
+	}
+	for _, chain := range c.ScanChains {
+		scanIn := NewSignal(chain.ScanIn + "@0")
+		tf.Circuit.AddPrimaryInput(scanIn)
+		tf.scanIn[chain] = scanIn
+
+		previous := scanIn
+		for _, cell := range chain.Cells {
+			value := previous
+			if cell.Inverted {
+				value = tf.Circuit.connect(NOT, []*Signal{previous}, NewSignal(cell.FlipFlop.ID+"_shift@0"))
+			}
+			next[index[cell.FlipFlop]] = value
+			previous = signal(cell.FlipFlop.Q)
+		}
+	}
+}
+
+// Frames returns the number of frames
+func (tf *TimeFrames) Frames() int {
+	return len(tf.copies)
+}
+
+// Copy returns the copy of a line of the original circuit in the given frame
+func (tf *TimeFrames) Copy(frame int, signal *Signal) *Signal {
+	return tf.copies[frame][signal]
+}
+
+// InitialState returns the line holding the state of the flip-flop before
+// the first frame, a primary input if the flip-flop is scanned
+func (tf *TimeFrames) InitialState(ff *FlipFlop) *Signal {
+	return tf.initial[ff]
+}
+
+// ScanInput returns the scan input of the chain in a launch-on-shift
+// expansion, nil for other expansions
+func (tf *TimeFrames) ScanInput(chain *ScanChain) *Signal {
+	return tf.scanIn[chain]
+}
+
+// Steady returns a line of the unrolled circuit that is 1 exactly when the
+// line of the original circuit stays at the value from the first frame to
+// the second without a hazard. An input is steady if both frames give it the
+// value, an AND gate is steady at 1 if all its inputs are and steady at 0 if
+// one of them is, an OR gate is the dual. The gates computing it are added on
+// first use, the bound and head lines must be identified again afterwards.
+func (tf *TimeFrames) Steady(signal *Signal, value SignalValue) *Signal {
+	if tf.steady[value] == nil {
+		tf.steady[value] = make(map[*Signal]*Signal)
+	}
+	if line := tf.steady[value][signal]; line != nil {
+		return line
+	}
+
+	id := fmt.Sprintf("%s@steady%d", signal.ID, value)
+	both := []*Signal{tf.Copy(0, signal), tf.Copy(1, signal)}
+	var line *Signal
+	switch {
+	case signal.FanIn == nil && value == ONE:
+		line = tf.Circuit.connect(AND, both, NewSignal(id))
+	case signal.FanIn == nil:
+		either := tf.Circuit.connect(OR, both, NewSignal(id+"_n"))
+		line = tf.Circuit.connect(NOT, []*Signal{either}, NewSignal(id))
+	case signal.FanIn.Type == NOT:
+		line = tf.Circuit.connect(AND, []*Signal{tf.Steady(signal.FanIn.Inputs[0], 1-value)}, NewSignal(id))
+	default:
+		gate := signal.FanIn
+		inputs := make([]*Signal, len(gate.Inputs))
+		for i, input := range gate.Inputs {
+			inputs[i] = tf.Steady(input, value)
+		}
+		// All inputs must be steady at the non-controlling value, one is enough at the controlling one
+		gateType := AND
+		if gate.IsControllingValue(value) {
+			gateType = OR
+		}
+		line = tf.Circuit.connect(gateType, inputs, NewSignal(id))
+	}
+	tf.steady[value][signal] = line
+	return line
+}
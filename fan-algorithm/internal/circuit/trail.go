@@ -0,0 +1,95 @@
+// trail.go
+package circuit
+
+// trailEntry records the value a signal had before an assignment
+type trailEntry struct {
+	signal   *Signal
+	previous SignalValue
+}
+
+// Trail records every assignment made through Signal.Assign so that search
+// algorithms can undo exactly the assignments made since a decision level or
+// snapshot instead of resetting the whole circuit
+type Trail struct {
+	entries []trailEntry
+	levels  []int // Trail length at the start of every decision level
+	signals int   // Number of circuit signals attached to the trail
+}
+
+// Trail returns the assignment trail of the circuit, creating it on first use.
+// Signals appended to c.Signals since the last call are attached to it.
+func (c *Circuit) Trail() *Trail {
+	if c.trail == nil {
+		c.trail = &Trail{
+			entries: make([]trailEntry, 0),
+			levels:  make([]int, 0),
+		}
+	}
+	if c.trail.signals > len(c.Signals) {
+		c.trail.signals = 0 // The signal list was replaced
+	}
+	for _, signal := range c.Signals[c.trail.signals:] {
+		signal.trail = c.trail
+	}
+	c.trail.signals = len(c.Signals)
+	return c.trail
+}
+
+// Assign sets the value of the signal, recording the previous value on the
+// trail. Search engines write values only through Assign.
+func (s *Signal) Assign(v SignalValue) {
+	if s.Value == v {
+		return
+	}
+	if s.trail != nil {
+		s.trail.entries = append(s.trail.entries, trailEntry{signal: s, previous: s.Value})
+	}
+	s.Value = v
+}
+
+// NewLevel opens a decision level, later assignments belong to it
+func (t *Trail) NewLevel() {
+	t.levels = append(t.levels, len(t.entries))
+}
+
+// Level returns the current decision level
+func (t *Trail) Level() int {
+	return len(t.levels)
+}
+
+// Len returns the number of recorded assignments, usable as a snapshot position
+func (t *Trail) Len() int {
+	return len(t.entries)
+}
+
+// UndoTo restores the values recorded after the given position, most recent
+// first. Decision levels starting at or after the position are dropped.
+func (t *Trail) UndoTo(position int) {
+	t.undo(position)
+	for len(t.levels) > 0 && t.levels[len(t.levels)-1] >= position {
+		t.levels = t.levels[:len(t.levels)-1]
+	}
+}
+
+// BacktrackTo undoes every assignment made above the given decision level
+func (t *Trail) BacktrackTo(level int) {
+	if level >= len(t.levels) {
+		return
+	}
+	t.undo(t.levels[level])
+	t.levels = t.levels[:level]
+}
+
+func (t *Trail) undo(position int) {
+	for i := len(t.entries) - 1; i >= position; i-- {
+		entry := t.entries[i]
+		entry.signal.Value = entry.previous
+	}
+	t.entries = t.entries[:position]
+}
+
+// Clear forgets all recorded assignments without changing any value
+func (t *Trail) Clear() {
+	t.entries = t.entries[:0]
+	t.levels = t.levels[:0]
+}
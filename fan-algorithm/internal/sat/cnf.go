@@ -0,0 +1,29 @@
+// cnf.go
+package sat
+
+// CNF represents a formula in conjunctive normal form.
+// Variables are numbered from 1, literals use the DIMACS convention
+// where -v is the negation of variable v.
+type CNF struct {
+	NumVars int
+	Clauses [][]int
+}
+
+func NewCNF() *CNF {
+	return &CNF{
+		Clauses: make([][]int, 0),
+	}
+}
+
+// NewVar allocates a fresh variable
+func (f *CNF) NewVar() int {
+	f.NumVars++
+	return f.NumVars
+}
+
+// AddClause appends a disjunction of literals to the formula
+func (f *CNF) AddClause(literals ...int) {
+	clause := make([]int, len(literals))
+	copy(clause, literals)
+	f.Clauses = append(f.Clauses, clause)
+}
@@ -0,0 +1,161 @@
+// dimacs.go
+package sat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDIMACS writes the formula in DIMACS CNF format, comments go to the header
+func (f *CNF) WriteDIMACS(w io.Writer, comments ...string) error {
+	bw := bufio.NewWriter(w)
+	for _, comment := range comments {
+		fmt.Fprintf(bw, "c %s\n", comment)
+	}
+	fmt.Fprintf(bw, "p cnf %d %d\n", f.NumVars, len(f.Clauses))
+	for _, clause := range f.Clauses {
+		for _, literal := range clause {
+			fmt.Fprintf(bw, "%d ", literal)
+		}
+		fmt.Fprintln(bw, "0")
+	}
+	return bw.Flush()
+}
+
+// ReadDIMACS parses a formula in DIMACS CNF format
+func ReadDIMACS(r io.Reader) (*CNF, error) {
+	f := NewCNF()
+	clause := make([]int, 0)
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "c") || strings.HasPrefix(line, "%") {
+			continue
+		}
+		if strings.HasPrefix(line, "p") {
+			fields := strings.Fields(line)
+			if len(fields) != 4 || fields[1] != "cnf" {
+				return nil, fmt.Errorf("invalid DIMACS header: %s", line)
+			}
+			numVars, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid variable count: %s", fields[2])
+			}
+			f.NumVars = numVars
+			continue
+		}
+
+		for _, field := range strings.Fields(line) {
+			literal, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid literal: %s", field)
+			}
+			if literal == 0 {
+				f.AddClause(clause...)
+				clause = clause[:0]
+				continue
+			}
+			if v := variable(literal); v > f.NumVars {
+				f.NumVars = v
+			}
+			clause = append(clause, literal)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(clause) > 0 {
+		f.AddClause(clause...)
+	}
+	return f, nil
+}
+
+// WriteSolution writes a solver result in the SAT competition output format
+func WriteSolution(w io.Writer, status Status, model []bool) error {
+	bw := bufio.NewWriter(w)
+	switch status {
+	case SATISFIABLE:
+		fmt.Fprintln(bw, "s SATISFIABLE")
+		fmt.Fprint(bw, "v")
+		for v := 1; v < len(model); v++ {
+			fmt.Fprintf(bw, " %d", literalOf(v, model[v]))
+		}
+		fmt.Fprintln(bw, " 0")
+	case UNSATISFIABLE:
+		fmt.Fprintln(bw, "s UNSATISFIABLE")
+	default:
+		fmt.Fprintln(bw, "s UNKNOWN")
+	}
+	return bw.Flush()
+}
+
+// ReadSolution parses the output of an external solver. Both the SAT competition
+// format ("s"/"v" lines) and the MiniSat result file format are accepted.
+// The model is indexed by variable, unmentioned variables are false.
+func ReadSolution(r io.Reader, numVars int) (Status, []bool, error) {
+	status := UNKNOWN
+	model := make([]bool, numVars+1)
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+
+		switch fields[0] {
+		case "s":
+			if len(fields) < 2 {
+				return UNKNOWN, nil, fmt.Errorf("missing solver status")
+			}
+			status = parseStatus(fields[1])
+			continue
+		case "SAT", "SATISFIABLE", "UNSAT", "UNSATISFIABLE", "INDET", "UNKNOWN":
+			status = parseStatus(fields[0])
+			continue
+		case "v":
+			fields = fields[1:]
+		}
+
+		for _, field := range fields {
+			literal, err := strconv.Atoi(field)
+			if err != nil {
+				return UNKNOWN, nil, fmt.Errorf("invalid literal in model: %s", field)
+			}
+			if literal == 0 {
+				continue
+			}
+			v := variable(literal)
+			if v > numVars {
+				return UNKNOWN, nil, fmt.Errorf("variable %d out of range", v)
+			}
+			model[v] = literal > 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return UNKNOWN, nil, err
+	}
+	return status, model, nil
+}
+
+func parseStatus(s string) Status {
+	switch s {
+	case "SAT", "SATISFIABLE":
+		return SATISFIABLE
+	case "UNSAT", "UNSATISFIABLE":
+		return UNSATISFIABLE
+	default:
+		return UNKNOWN
+	}
+}
+
+func literalOf(v int, value bool) int {
+	if value {
+		return v
+	}
+	return -v
+}
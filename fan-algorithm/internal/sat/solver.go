@@ -0,0 +1,360 @@
+// solver.go
+package sat
+
+// Status represents the outcome of a solver run
+type Status int
+
+const (
+	SATISFIABLE Status = iota
+	UNSATISFIABLE
+	UNKNOWN // Conflict limit reached
+)
+
+// Stats tracks solver effort
+type Stats struct {
+	Decisions    int
+	Conflicts    int
+	Propagations int
+	Restarts     int
+	Learnts      int
+}
+
+// clause stores its two watched literals at positions 0 and 1
+type clause struct {
+	literals []int
+	learnt   bool
+}
+
+// Solver is a conflict-driven clause learning SAT solver with two watched
+// literals, first-UIP learning, non-chronological backjumping, activity based
+// branching and geometric restarts
+type Solver struct {
+	MaxConflicts int // Zero means no limit
+	Stats        Stats
+
+	numVars  int
+	clauses  []*clause
+	learnts  []*clause
+	watches  map[int][]*clause // Clauses watching a literal
+	assigns  []int8            // Per variable: 0 unassigned, 1 true, -1 false
+	level    []int
+	reason   []*clause
+	polarity []bool // Saved phase per variable
+	activity []float64
+	varInc   float64
+	trail    []int
+	trailLim []int
+	qhead    int
+	unsat    bool
+}
+
+// NewSolver creates a solver loaded with the clauses of the formula
+func NewSolver(f *CNF) *Solver {
+	s := &Solver{
+		numVars:  f.NumVars,
+		clauses:  make([]*clause, 0, len(f.Clauses)),
+		learnts:  make([]*clause, 0),
+		watches:  make(map[int][]*clause),
+		assigns:  make([]int8, f.NumVars+1),
+		level:    make([]int, f.NumVars+1),
+		reason:   make([]*clause, f.NumVars+1),
+		polarity: make([]bool, f.NumVars+1),
+		activity: make([]float64, f.NumVars+1),
+		varInc:   1.0,
+		trail:    make([]int, 0, f.NumVars),
+		trailLim: make([]int, 0),
+	}
+	for _, literals := range f.Clauses {
+		s.addClause(literals)
+	}
+	return s
+}
+
+func variable(literal int) int {
+	if literal < 0 {
+		return -literal
+	}
+	return literal
+}
+
+// value returns 1 if the literal is true, -1 if false and 0 if unassigned
+func (s *Solver) value(literal int) int8 {
+	v := s.assigns[variable(literal)]
+	if literal < 0 {
+		return -v
+	}
+	return v
+}
+
+func (s *Solver) decisionLevel() int {
+	return len(s.trailLim)
+}
+
+func (s *Solver) addClause(literals []int) {
+	if s.unsat {
+		return
+	}
+
+	// Drop duplicate literals and tautologies
+	seen := make(map[int]bool)
+	unique := make([]int, 0, len(literals))
+	for _, literal := range literals {
+		if seen[-literal] {
+			return
+		}
+		if !seen[literal] {
+			seen[literal] = true
+			unique = append(unique, literal)
+		}
+	}
+
+	switch len(unique) {
+	case 0:
+		s.unsat = true
+	case 1:
+		switch s.value(unique[0]) {
+		case -1:
+			s.unsat = true
+		case 0:
+			s.enqueue(unique[0], nil)
+		}
+	default:
+		c := &clause{literals: unique}
+		s.clauses = append(s.clauses, c)
+		s.watch(c)
+	}
+}
+
+func (s *Solver) watch(c *clause) {
+	s.watches[c.literals[0]] = append(s.watches[c.literals[0]], c)
+	s.watches[c.literals[1]] = append(s.watches[c.literals[1]], c)
+}
+
+func (s *Solver) enqueue(literal int, from *clause) {
+	v := variable(literal)
+	if literal > 0 {
+		s.assigns[v] = 1
+	} else {
+		s.assigns[v] = -1
+	}
+	s.level[v] = s.decisionLevel()
+	s.reason[v] = from
+	s.trail = append(s.trail, literal)
+}
+
+// propagate performs unit propagation, returning a conflicting clause if any
+func (s *Solver) propagate() *clause {
+	for s.qhead < len(s.trail) {
+		falseLiteral := -s.trail[s.qhead]
+		s.qhead++
+		s.Stats.Propagations++
+
+		watchers := s.watches[falseLiteral]
+		kept := watchers[:0]
+		var conflict *clause
+
+		for i, c := range watchers {
+			if conflict != nil {
+				kept = append(kept, watchers[i:]...)
+				break
+			}
+
+			// Make sure the false literal is at position 1
+			if c.literals[0] == falseLiteral {
+				c.literals[0], c.literals[1] = c.literals[1], c.literals[0]
+			}
+			if s.value(c.literals[0]) == 1 {
+				kept = append(kept, c)
+				continue
+			}
+
+			// Look for a new literal to watch
+			moved := false
+			for k := 2; k < len(c.literals); k++ {
+				if s.value(c.literals[k]) != -1 {
+					c.literals[1], c.literals[k] = c.literals[k], c.literals[1]
+					s.watches[c.literals[1]] = append(s.watches[c.literals[1]], c)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+
+			kept = append(kept, c)
+			if s.value(c.literals[0]) == -1 {
+				conflict = c
+			} else {
+				s.enqueue(c.literals[0], c)
+			}
+		}
+
+		s.watches[falseLiteral] = kept
+		if conflict != nil {
+			return conflict
+		}
+	}
+	return nil
+}
+
+// analyze derives a first-UIP learnt clause from the conflict and returns
+// it together with the decision level to jump back to
+func (s *Solver) analyze(conflict *clause) ([]int, int) {
+	seen := make([]bool, s.numVars+1)
+	learnt := []int{0} // Position 0 is reserved for the asserting literal
+	counter := 0
+	literal := 0
+	index := len(s.trail) - 1
+
+	for {
+		start := 0
+		if literal != 0 {
+			start = 1 // Skip the implied literal of a reason clause
+		}
+		for _, q := range conflict.literals[start:] {
+			v := variable(q)
+			if seen[v] || s.level[v] == 0 {
+				continue
+			}
+			seen[v] = true
+			s.bumpActivity(v)
+			if s.level[v] == s.decisionLevel() {
+				counter++
+			} else {
+				learnt = append(learnt, q)
+			}
+		}
+
+		// Next literal of the current level on the trail
+		for !seen[variable(s.trail[index])] {
+			index--
+		}
+		literal = s.trail[index]
+		index--
+		conflict = s.reason[variable(literal)]
+		seen[variable(literal)] = false
+		counter--
+		if counter == 0 {
+			break
+		}
+	}
+	learnt[0] = -literal
+
+	// Backjump to the second highest level in the clause
+	backjump := 0
+	for i := 1; i < len(learnt); i++ {
+		if lvl := s.level[variable(learnt[i])]; lvl > backjump {
+			backjump = lvl
+			learnt[1], learnt[i] = learnt[i], learnt[1]
+		}
+	}
+	return learnt, backjump
+}
+
+// cancelUntil undoes all assignments above the given decision level
+func (s *Solver) cancelUntil(level int) {
+	if s.decisionLevel() <= level {
+		return
+	}
+	for i := len(s.trail) - 1; i >= s.trailLim[level]; i-- {
+		v := variable(s.trail[i])
+		s.polarity[v] = s.trail[i] > 0
+		s.assigns[v] = 0
+		s.reason[v] = nil
+	}
+	s.trail = s.trail[:s.trailLim[level]]
+	s.trailLim = s.trailLim[:level]
+	s.qhead = len(s.trail)
+}
+
+func (s *Solver) bumpActivity(v int) {
+	s.activity[v] += s.varInc
+	if s.activity[v] > 1e100 {
+		for i := range s.activity {
+			s.activity[i] *= 1e-100
+		}
+		s.varInc *= 1e-100
+	}
+}
+
+// pickBranchVariable returns the unassigned variable with the highest activity, or 0
+func (s *Solver) pickBranchVariable() int {
+	best := 0
+	for v := 1; v <= s.numVars; v++ {
+		if s.assigns[v] == 0 && (best == 0 || s.activity[v] > s.activity[best]) {
+			best = v
+		}
+	}
+	return best
+}
+
+// Solve searches for a satisfying assignment
+func (s *Solver) Solve() Status {
+	if s.unsat {
+		return UNSATISFIABLE
+	}
+
+	restartLimit := 100
+	conflictsSinceRestart := 0
+
+	for {
+		conflict := s.propagate()
+		if conflict != nil {
+			s.Stats.Conflicts++
+			conflictsSinceRestart++
+			if s.decisionLevel() == 0 {
+				s.unsat = true
+				return UNSATISFIABLE
+			}
+
+			learnt, backjump := s.analyze(conflict)
+			s.cancelUntil(backjump)
+			if len(learnt) == 1 {
+				s.enqueue(learnt[0], nil)
+			} else {
+				c := &clause{literals: learnt, learnt: true}
+				s.learnts = append(s.learnts, c)
+				s.watch(c)
+				s.enqueue(learnt[0], c)
+				s.Stats.Learnts++
+			}
+			s.varInc *= 1.05
+
+			if s.MaxConflicts > 0 && s.Stats.Conflicts >= s.MaxConflicts {
+				s.cancelUntil(0)
+				return UNKNOWN
+			}
+			continue
+		}
+
+		if conflictsSinceRestart >= restartLimit {
+			s.cancelUntil(0)
+			conflictsSinceRestart = 0
+			restartLimit = restartLimit * 3 / 2
+			s.Stats.Restarts++
+			continue
+		}
+
+		v := s.pickBranchVariable()
+		if v == 0 {
+			return SATISFIABLE
+		}
+		s.Stats.Decisions++
+		s.trailLim = append(s.trailLim, len(s.trail))
+		if s.polarity[v] {
+			s.enqueue(v, nil)
+		} else {
+			s.enqueue(-v, nil)
+		}
+	}
+}
+
+// Model returns the value of every variable after a satisfiable run, indexed by variable
+func (s *Solver) Model() []bool {
+	model := make([]bool, s.numVars+1)
+	for v := 1; v <= s.numVars; v++ {
+		model[v] = s.assigns[v] == 1
+	}
+	return model
+}
@@ -0,0 +1,135 @@
+package sensitization
+
+import (
+	"container/heap"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/utils"
+)
+
+// LongestPaths returns the k longest structural paths from a primary input to
+// a primary output, longest first. The length of a path is the sum of the
+// propagation delays of its gates, its gate count unless delays are
+// annotated. Paths are grown best first, bounded by the longest way from
+// their last line to an output, so only the returned paths are completed.
+func (pf *PathFinder) LongestPaths(k int) []*Path {
+	c := pf.Circuit
+	remaining := make(map[*circuit.Signal]int)
+	order := c.TopologicalOrder()
+	for i := len(order) - 1; i >= 0; i-- {
+		signal := order[i]
+		longest := -1
+		if isPrimaryOutput(c, signal) {
+			longest = 0
+		}
+		for _, fanout := range signal.Fanouts {
+			if rest, ok := remaining[fanout]; ok && fanout.FanIn.PropagationDelay()+rest > longest {
+				longest = fanout.FanIn.PropagationDelay() + rest
+			}
+		}
+		if longest >= 0 {
+			remaining[signal] = longest
+		}
+	}
+
+	queue := &pathQueue{}
+	for _, input := range c.PrimaryInputs {
+		if rest, ok := remaining[input]; ok {
+			queue.push(&partialPath{signals: []*circuit.Signal{input}, bound: rest})
+		}
+	}
+
+	paths := make([]*Path, 0, k)
+	for queue.Len() > 0 && len(paths) < k {
+		partial := heap.Pop(queue).(*partialPath)
+		if partial.complete {
+			paths = append(paths, partial.path())
+			continue
+		}
+
+		last := partial.signals[len(partial.signals)-1]
+		if isPrimaryOutput(c, last) {
+			queue.push(&partialPath{signals: partial.signals, delay: partial.delay, bound: partial.delay, complete: true})
+		}
+		for _, fanout := range last.Fanouts {
+			rest, ok := remaining[fanout]
+			if !ok {
+				continue
+			}
+			signals := append(append([]*circuit.Signal{}, partial.signals...), fanout)
+			delay := partial.delay + fanout.FanIn.PropagationDelay()
+			queue.push(&partialPath{signals: signals, delay: delay, bound: delay + rest})
+		}
+	}
+	return paths
+}
+
+// OffPathInputs returns the inputs of the i-th gate of the path that are not on it
+func (p *Path) OffPathInputs(i int) []*circuit.Signal {
+	inputs := make([]*circuit.Signal, 0, len(p.Gates[i].Inputs))
+	for _, input := range p.Gates[i].Inputs {
+		if input != p.Signals[i] {
+			inputs = append(inputs, input)
+		}
+	}
+	return inputs
+}
+
+// isPrimaryOutput checks if the signal is an output of the circuit, IsPrimary
+// also holds for inputs
+func isPrimaryOutput(c *circuit.Circuit, signal *circuit.Signal) bool {
+	for _, output := range c.PrimaryOutputs {
+		if output == signal {
+			return true
+		}
+	}
+	return false
+}
+
+// partialPath is a path from a primary input grown during the search
+type partialPath struct {
+	signals  []*circuit.Signal
+	delay    int
+	bound    int  // Length of the longest complete path it can grow into
+	complete bool // Ends at a primary output and grows no further
+	sequence int  // Insertion order, breaks ties between equal bounds
+}
+
+func (p *partialPath) path() *Path {
+	gates := make([]*circuit.Gate, 0, len(p.signals)-1)
+	for _, signal := range p.signals[1:] {
+		gates = append(gates, signal.FanIn)
+	}
+	return &Path{Gates: gates, Signals: p.signals, Score: utils.CalculatePathScore(gates), Delay: p.delay}
+}
+
+// pathQueue is a max-heap of partial paths by bound
+type pathQueue struct {
+	paths []*partialPath
+	count int
+}
+
+func (q *pathQueue) push(p *partialPath) {
+	p.sequence = q.count
+	q.count++
+	heap.Push(q, p)
+}
+
+func (q *pathQueue) Len() int { return len(q.paths) }
+
+func (q *pathQueue) Less(i, j int) bool {
+	if q.paths[i].bound != q.paths[j].bound {
+		return q.paths[i].bound > q.paths[j].bound
+	}
+	return q.paths[i].sequence < q.paths[j].sequence
+}
+
+func (q *pathQueue) Swap(i, j int) { q.paths[i], q.paths[j] = q.paths[j], q.paths[i] }
+
+func (q *pathQueue) Push(x any) { q.paths = append(q.paths, x.(*partialPath)) }
+
+func (q *pathQueue) Pop() any {
+	last := q.paths[len(q.paths)-1]
+	q.paths = q.paths[:len(q.paths)-1]
+	return last
+}
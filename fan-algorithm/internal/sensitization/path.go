@@ -1,8 +1,11 @@
 package sensitization
 
 import (
+	"time"
+
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/utils"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
 )
 
 // Path represents a sensitization path in the circuit
@@ -10,55 +13,102 @@ type Path struct {
 	Gates   []*circuit.Gate
 	Signals []*circuit.Signal
 	Score   int // Path priority score
+	Delay   int // Sum of the gate delays along the path
 }
 
 // PathFinder handles path analysis for sensitization
 type PathFinder struct {
-	Circuit *circuit.Circuit
+	Circuit    *circuit.Circuit
+	dominators *circuit.DominatorTree
 }
 
 func NewPathFinder(c *circuit.Circuit) *PathFinder {
 	return &PathFinder{Circuit: c}
 }
 
-// FindUniqueSensitizationPaths finds paths that must be uniquely sensitized
+// Dominators returns the circuit's dominator tree, computing it on first use
+func (pf *PathFinder) Dominators() *circuit.DominatorTree {
+	if pf.dominators == nil {
+		pf.dominators = pf.Circuit.ComputeDominators()
+	}
+	return pf.dominators
+}
+
+// FindDominators returns the signals every fault effect leaving the D-frontier must pass, nearest first
+func (pf *PathFinder) FindDominators(dFrontier []*circuit.Gate) []*circuit.Signal {
+	outputs := make([]*circuit.Signal, len(dFrontier))
+	for i, gate := range dFrontier {
+		outputs[i] = gate.Output
+	}
+	return pf.Dominators().CommonDominators(outputs)
+}
+
+// FindUniqueSensitizationPaths returns the path through the dominators of the whole D-frontier
 func (pf *PathFinder) FindUniqueSensitizationPaths(dFrontier []*circuit.Gate) []*Path {
-	if len(dFrontier) != 1 {
+	dominators := pf.FindDominators(dFrontier)
+	if len(dominators) == 0 {
 		return nil
 	}
 
-	paths := make([]*Path, 0)
-	startGate := dFrontier[0]
-
-	// Find all paths to primary outputs
-	var dfs func(*circuit.Gate, []*circuit.Gate)
-	dfs = func(gate *circuit.Gate, currentPath []*circuit.Gate) {
-		if gate == nil {
-			return
+	path := &Path{
+		Gates:   make([]*circuit.Gate, 0, len(dominators)),
+		Signals: dominators,
+	}
+	for _, signal := range dominators {
+		if signal.FanIn != nil {
+			path.Gates = append(path.Gates, signal.FanIn)
 		}
+	}
+	path.Score = utils.CalculatePathScore(path.Gates)
 
-		newPath := append(currentPath, gate)
+	return []*Path{path}
+}
 
-		// If reached primary output
-		if gate.Output.IsPrimary {
-			path := &Path{
-				Gates: append([]*circuit.Gate{}, newPath...),
-				Score: utils.CalculatePathScore(newPath),
-			}
-			paths = append(paths, path)
+// UniqueSensitization returns the non-controlling values required on the off-path
+// inputs of every dominator of the D-frontier. Off-path inputs are those that can
+// not carry the fault effect, i.e. lie outside the fanout cone of the D-frontier.
+func (pf *PathFinder) UniqueSensitization(dFrontier []*circuit.Gate, level int) []types.Assignment {
+	dominators := pf.FindDominators(dFrontier)
+	if len(dominators) == 0 {
+		return nil
+	}
+
+	cone := make(map[*circuit.Signal]bool)
+	var mark func(*circuit.Signal)
+	mark = func(signal *circuit.Signal) {
+		if cone[signal] {
 			return
 		}
+		cone[signal] = true
+		for _, fanout := range signal.Fanouts {
+			mark(fanout)
+		}
+	}
+	for _, gate := range dFrontier {
+		mark(gate.Output)
+	}
 
-		// Continue through fanouts
-		for _, fanout := range gate.Output.Fanouts {
-			if fanout.FanIn != nil {
-				dfs(fanout.FanIn, newPath)
+	assignments := make([]types.Assignment, 0)
+	for _, signal := range dominators {
+		gate := signal.FanIn
+		if gate == nil || gate.Type == circuit.NOT {
+			continue
+		}
+		for _, input := range gate.Inputs {
+			if cone[input] || input.IsFaulty() {
+				continue
 			}
+			assignments = append(assignments, types.Assignment{
+				Signal:    input,
+				Value:     gate.GetNonControllingValue(),
+				Reason:    types.UNIQUE_SENSITIZATION,
+				Level:     level,
+				TimeStamp: time.Now(),
+			})
 		}
 	}
 
-	dfs(startGate, make([]*circuit.Gate, 0))
-	return paths
+	return assignments
 }
 
 // GetMandatorySignals finds signals that must be sensitized
@@ -1,91 +1,215 @@
 package strategy
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/utils"
 )
 
 // DecisionNode represents a node in the decision tree
 type DecisionNode struct {
-	Signal   *circuit.Signal
-	Value    circuit.SignalValue
-	Tried    bool // Whether alternative value has been tried
-	Parent   *DecisionNode
-	Children []*DecisionNode
+	Signal        *circuit.Signal
+	Value         circuit.SignalValue
+	Tried         bool // Whether alternative value has been tried
+	Parent        *DecisionNode
+	Children      []*DecisionNode
+	Level         int          // Depth of the node, the root is level 0
+	Discrepancies int          // Alternative values taken on the path from the root
+	Failed        bool         // The subtree below the node holds no solution
+	Conflicts     map[int]bool // Decision levels the failed branches of this decision depend on
 }
 
-// DecisionTree manages backtracking decisions
+// DecisionTree manages backtracking decisions. Every branch tried stays in the
+// tree, the current path holds the decisions in effect.
 type DecisionTree struct {
-	Root    *DecisionNode
+	Root    *DecisionNode // Holds no decision, its children start the search
 	Current *DecisionNode
 	Circuit *circuit.Circuit
+
+	MaxDiscrepancies int  // Alternatives allowed on a path, negative means no limit
+	Pruned           bool // A branch was skipped because of the discrepancy limit
+	Backjumps        int  // Backtracks that skipped decisions unrelated to the conflict
+	Rotation         int  // Rank of the candidate taken as first decision, advanced by restarts
 }
 
 func NewDecisionTree(c *circuit.Circuit) *DecisionTree {
+	root := &DecisionNode{}
 	return &DecisionTree{
-		Circuit: c,
+		Root:             root,
+		Current:          root,
+		Circuit:          c,
+		MaxDiscrepancies: -1,
 	}
 }
 
-// AddDecision adds a new decision to the tree
+// AddDecision adds a new decision below the current one
 func (dt *DecisionTree) AddDecision(signal *circuit.Signal, value circuit.SignalValue) *DecisionNode {
 	node := &DecisionNode{
-		Signal: signal,
-		Value:  value,
-		Tried:  false,
+		Signal:        signal,
+		Value:         value,
+		Tried:         false,
+		Parent:        dt.Current,
+		Level:         dt.Current.Level + 1,
+		Discrepancies: dt.Current.Discrepancies,
 	}
+	dt.Current.Children = append(dt.Current.Children, node)
+	dt.Current = node
+	return node
+}
 
-	if dt.Root == nil {
-		dt.Root = node
-		dt.Current = node
-	} else {
-		node.Parent = dt.Current
-		dt.Current.Children = append(dt.Current.Children, node)
-		dt.Current = node
-	}
+// Depth returns the number of decisions in effect
+func (dt *DecisionTree) Depth() int {
+	return dt.Current.Level
+}
 
-	return node
+// Path returns the decisions in effect, from the first to the current one
+func (dt *DecisionTree) Path() []*DecisionNode {
+	path := make([]*DecisionNode, dt.Current.Level)
+	for node := dt.Current; node != dt.Root; node = node.Parent {
+		path[node.Level-1] = node
+	}
+	return path
 }
 
 // Backtrack returns to previous decision point and tries alternative
 func (dt *DecisionTree) Backtrack() bool {
-	if dt.Current == nil {
-		return false
+	levels := make(map[int]bool)
+	for level := 1; level <= dt.Depth(); level++ {
+		levels[level] = true
 	}
+	return dt.Backjump(levels) != nil
+}
+
+// Backjump backtracks from a failure depending on the given decision levels.
+// Decisions above the deepest of them are skipped, and once both values of a
+// decision failed, the levels both branches depend on are carried to the
+// parent. The alternative value is added as a sibling branch and returned,
+// nil means no decision is left to flip.
+func (dt *DecisionTree) Backjump(conflict map[int]bool) *DecisionNode {
+	for {
+		deepest := 0
+		for level := range conflict {
+			if level > deepest && level <= dt.Depth() {
+				deepest = level
+			}
+		}
+
+		// Every decision below the deepest level is unrelated to the conflict
+		if deepest < dt.Depth() {
+			if deepest > 0 {
+				dt.Backjumps++
+			}
+			for dt.Current.Level > deepest {
+				dt.Current.Failed = true
+				dt.Current = dt.Current.Parent
+			}
+		}
+		if deepest == 0 {
+			// The conflict holds whatever is decided
+			dt.Current = dt.Root
+			return nil
+		}
 
-	// Try alternative value if not tried yet
-	if !dt.Current.Tried {
-		dt.Current.Tried = true
-		dt.Current.Value = utils.GetAlternativeValue(dt.Current.Value)
-		return true
+		node := dt.Current
+		node.Failed = true
+		if node.Conflicts == nil {
+			node.Conflicts = make(map[int]bool)
+		}
+		for level := range conflict {
+			if level < deepest {
+				node.Conflicts[level] = true
+			}
+		}
+
+		if !node.Tried {
+			node.Tried = true
+			if dt.MaxDiscrepancies < 0 || node.Discrepancies < dt.MaxDiscrepancies {
+				alternative := &DecisionNode{
+					Signal:        node.Signal,
+					Value:         utils.GetAlternativeValue(node.Value),
+					Tried:         true,
+					Parent:        node.Parent,
+					Level:         node.Level,
+					Discrepancies: node.Discrepancies + 1,
+					Conflicts:     node.Conflicts,
+				}
+				node.Parent.Children = append(node.Parent.Children, alternative)
+				dt.Current = alternative
+				return alternative
+			}
+
+			// The unexplored alternative gives no conflict to carry upwards
+			dt.Pruned = true
+			for level := 1; level < deepest; level++ {
+				node.Conflicts[level] = true
+			}
+		}
+
+		// Both values failed, continue with the parent levels responsible
+		conflict = node.Conflicts
+		dt.Current = node.Parent
 	}
+}
+
+// Restart abandons the current path, the branches tried so far stay recorded
+func (dt *DecisionTree) Restart() {
+	dt.Current = dt.Root
+	dt.Pruned = false
+}
 
-	// Move to parent node
-	if dt.Current.Parent != nil {
-		dt.Current = dt.Current.Parent
-		return dt.Backtrack()
+// Size returns the number of decisions recorded in the tree
+func (dt *DecisionTree) Size() int {
+	size := 0
+	var count func(*DecisionNode)
+	count = func(node *DecisionNode) {
+		for _, child := range node.Children {
+			size++
+			count(child)
+		}
 	}
+	count(dt.Root)
+	return size
+}
+
+// WriteDOT exports the tree in Graphviz DOT format, failed branches are dashed
+func (dt *DecisionTree) WriteDOT(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	ids := make(map[*DecisionNode]int)
 
-	return false
+	fmt.Fprintln(out, "digraph decisions {")
+	fmt.Fprintln(out, "  n0 [label=\"root\"];")
+	var write func(*DecisionNode)
+	write = func(node *DecisionNode) {
+		for _, child := range node.Children {
+			ids[child] = len(ids) + 1
+			style := "solid"
+			if child.Failed {
+				style = "dashed"
+			}
+			fmt.Fprintf(out, "  n%d [label=\"%s=%d\", style=%s];\n", ids[child], child.Signal.ID, child.Value, style)
+			fmt.Fprintf(out, "  n%d -> n%d;\n", ids[node], ids[child])
+			write(child)
+		}
+	}
+	ids[dt.Root] = 0
+	write(dt.Root)
+	fmt.Fprintln(out, "}")
+
+	return out.Flush()
 }
 
 // Reset circuit to state before current decision
 func (dt *DecisionTree) ResetToCurrentState() {
 	// Reset all signals to X
 	for _, signal := range dt.Circuit.Signals {
-		if !signal.IsPrimary {
-			signal.SetValue(circuit.X)
-		}
+		signal.Assign(circuit.X)
 	}
 
-	// Replay decisions up to current node
-	node := dt.Root
-	for node != nil {
-		node.Signal.SetValue(node.Value)
-		if len(node.Children) > 0 {
-			node = node.Children[0]
-		} else {
-			break
-		}
+	// Replay decisions on the current path
+	for _, node := range dt.Path() {
+		node.Signal.Assign(node.Value)
 	}
 }
@@ -0,0 +1,156 @@
+package strategy
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+// Candidate is a decision the search may take next
+type Candidate struct {
+	Signal *circuit.Signal
+	Value  circuit.SignalValue
+}
+
+// DecisionHeuristic ranks candidate decisions given the circuit state, best first.
+// Candidates arrive in the order the backtrace produced them.
+type DecisionHeuristic interface {
+	Name() string
+	Rank(c *circuit.Circuit, candidates []Candidate) []Candidate
+}
+
+// ConflictListener is implemented by heuristics learning from conflicts
+type ConflictListener interface {
+	OnConflict(signals []*circuit.Signal)
+}
+
+// NewDecisionHeuristic creates the built-in heuristic of the given kind
+func NewDecisionHeuristic(kind types.DecisionHeuristicKind, seed int64) DecisionHeuristic {
+	switch kind {
+	case types.SCOAP_HEURISTIC:
+		return NewSCOAPHeuristic()
+	case types.FANOUT_HEURISTIC:
+		return &FanoutHeuristic{}
+	case types.ACTIVITY_HEURISTIC:
+		return NewActivityHeuristic()
+	case types.RANDOM_HEURISTIC:
+		return NewRandomHeuristic(seed)
+	default:
+		return &BacktraceOrderHeuristic{}
+	}
+}
+
+// BacktraceOrderHeuristic keeps the order of the backtrace objectives
+type BacktraceOrderHeuristic struct{}
+
+func (h *BacktraceOrderHeuristic) Name() string { return "backtrace-order" }
+
+func (h *BacktraceOrderHeuristic) Rank(c *circuit.Circuit, candidates []Candidate) []Candidate {
+	return candidates
+}
+
+// SCOAPHeuristic prefers the decision with the lowest SCOAP controllability of its value
+type SCOAPHeuristic struct {
+	circuit *circuit.Circuit // Circuit the measures were computed for
+}
+
+func NewSCOAPHeuristic() *SCOAPHeuristic {
+	return &SCOAPHeuristic{}
+}
+
+func (h *SCOAPHeuristic) Name() string { return "scoap" }
+
+func (h *SCOAPHeuristic) Rank(c *circuit.Circuit, candidates []Candidate) []Candidate {
+	if h.circuit != c {
+		c.ComputeSCOAP()
+		h.circuit = c
+	}
+
+	ranked := append([]Candidate{}, candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Signal.Controllability(ranked[i].Value) < ranked[j].Signal.Controllability(ranked[j].Value)
+	})
+	return ranked
+}
+
+// FanoutHeuristic prefers decisions on lines with more fanout branches,
+// whose values constrain more of the circuit
+type FanoutHeuristic struct{}
+
+func (h *FanoutHeuristic) Name() string { return "fanout" }
+
+func (h *FanoutHeuristic) Rank(c *circuit.Circuit, candidates []Candidate) []Candidate {
+	ranked := append([]Candidate{}, candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return len(ranked[i].Signal.Fanouts) > len(ranked[j].Signal.Fanouts)
+	})
+	return ranked
+}
+
+// ActivityHeuristic is a VSIDS-like heuristic preferring lines that took part
+// in recent conflicts. Activities decay so older conflicts count less.
+type ActivityHeuristic struct {
+	activity  map[*circuit.Signal]float64
+	increment float64
+	decay     float64
+}
+
+func NewActivityHeuristic() *ActivityHeuristic {
+	return &ActivityHeuristic{
+		activity:  make(map[*circuit.Signal]float64),
+		increment: 1.0,
+		decay:     0.95,
+	}
+}
+
+func (h *ActivityHeuristic) Name() string { return "activity" }
+
+func (h *ActivityHeuristic) Rank(c *circuit.Circuit, candidates []Candidate) []Candidate {
+	ranked := append([]Candidate{}, candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return h.activity[ranked[i].Signal] > h.activity[ranked[j].Signal]
+	})
+	return ranked
+}
+
+// OnConflict bumps the activity of the lines in a conflict
+func (h *ActivityHeuristic) OnConflict(signals []*circuit.Signal) {
+	for _, signal := range signals {
+		h.activity[signal] += h.increment
+	}
+
+	// Growing the increment decays all earlier bumps, rescale before it overflows
+	h.increment /= h.decay
+	if h.increment > 1e100 {
+		for signal := range h.activity {
+			h.activity[signal] *= 1e-100
+		}
+		h.increment *= 1e-100
+	}
+}
+
+// Activity returns the current activity of a line
+func (h *ActivityHeuristic) Activity(signal *circuit.Signal) float64 {
+	return h.activity[signal]
+}
+
+// RandomHeuristic ranks candidates in a random order, reproducible from its seed
+type RandomHeuristic struct {
+	random *rand.Rand
+}
+
+func NewRandomHeuristic(seed int64) *RandomHeuristic {
+	return &RandomHeuristic{random: rand.New(rand.NewSource(seed))}
+}
+
+func (h *RandomHeuristic) Name() string { return "random" }
+
+func (h *RandomHeuristic) Rank(c *circuit.Circuit, candidates []Candidate) []Candidate {
+	ranked := append([]Candidate{}, candidates...)
+	h.random.Shuffle(len(ranked), func(i, j int) {
+		ranked[i], ranked[j] = ranked[j], ranked[i]
+	})
+	return ranked
+}
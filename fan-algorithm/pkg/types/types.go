@@ -1,8 +1,12 @@
 package types
 
 import (
-	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
 )
 
 type AssignmentReason int
@@ -80,6 +84,7 @@ type Decision struct {
 	Level       int
 	Children    []Assignment // Implications resulting from this decision
 	Score       int          // Decision quality score
+	Conflicts   map[int]bool // Decision levels the failed branches of this decision depend on
 	TimeStamp   time.Time
 }
 
@@ -101,6 +106,7 @@ type CircuitState struct {
 	DecisionLevel       int
 	JustificationStatus map[*circuit.Signal]bool // Tracks justified signals
 	SensitizedPaths     []*SensitizationPath
+	TrailPosition       int // Position on the circuit trail the state can be restored to
 }
 
 // Add new method to check state consistency
@@ -123,6 +129,117 @@ func (cs *CircuitState) Clone() *CircuitState {
 	return newState
 }
 
+// LearnedImplication records that Signal=Value indirectly implies Target=TargetValue
+type LearnedImplication struct {
+	Signal      *circuit.Signal
+	Value       circuit.SignalValue
+	Target      *circuit.Signal
+	TargetValue circuit.SignalValue
+}
+
+// LearnedConstant is a value a signal holds in every consistent state
+type LearnedConstant struct {
+	Signal *circuit.Signal
+	Value  circuit.SignalValue
+}
+
+// LearnedImplicationTable indexes learned implications by their antecedent
+type LearnedImplicationTable struct {
+	entries   map[*circuit.Signal]map[circuit.SignalValue][]LearnedImplication
+	constants []LearnedConstant
+	size      int
+}
+
+func NewLearnedImplicationTable() *LearnedImplicationTable {
+	return &LearnedImplicationTable{
+		entries: make(map[*circuit.Signal]map[circuit.SignalValue][]LearnedImplication),
+	}
+}
+
+// Add stores a learned implication
+func (t *LearnedImplicationTable) Add(implication LearnedImplication) {
+	byValue, ok := t.entries[implication.Signal]
+	if !ok {
+		byValue = make(map[circuit.SignalValue][]LearnedImplication)
+		t.entries[implication.Signal] = byValue
+	}
+	byValue[implication.Value] = append(byValue[implication.Value], implication)
+	t.size++
+}
+
+// Lookup returns the implications learned for Signal=Value
+func (t *LearnedImplicationTable) Lookup(signal *circuit.Signal, value circuit.SignalValue) []LearnedImplication {
+	return t.entries[signal][value]
+}
+
+// AddConstant stores a learned constant
+func (t *LearnedImplicationTable) AddConstant(constant LearnedConstant) {
+	t.constants = append(t.constants, constant)
+}
+
+// Constants returns the learned constants
+func (t *LearnedImplicationTable) Constants() []LearnedConstant {
+	return t.constants
+}
+
+// Size returns the number of learned implications
+func (t *LearnedImplicationTable) Size() int {
+	return t.size
+}
+
+// ConflictLiteral is one assignment of a conflict clause
+type ConflictLiteral struct {
+	Signal *circuit.Signal
+	Value  circuit.SignalValue
+}
+
+// ConflictClause records a set of assignments that can't all hold in a test.
+// Global clauses hold in the fault-free circuit and are valid for every fault,
+// the others only for the fault they were learned on.
+type ConflictClause struct {
+	Literals []ConflictLiteral
+	Global   bool
+}
+
+// ConflictClauseDB stores learned conflict clauses without duplicates
+type ConflictClauseDB struct {
+	clauses []*ConflictClause
+	keys    map[string]bool
+}
+
+func NewConflictClauseDB() *ConflictClauseDB {
+	return &ConflictClauseDB{
+		clauses: make([]*ConflictClause, 0),
+		keys:    make(map[string]bool),
+	}
+}
+
+// Add stores a clause, returns false if an identical clause is already stored
+func (db *ConflictClauseDB) Add(clause *ConflictClause) bool {
+	literals := make([]string, len(clause.Literals))
+	for i, literal := range clause.Literals {
+		literals[i] = fmt.Sprintf("%s=%d", literal.Signal.ID, literal.Value)
+	}
+	sort.Strings(literals)
+	key := strings.Join(literals, ",")
+	if db.keys[key] {
+		return false
+	}
+	db.keys[key] = true
+	db.clauses = append(db.clauses, clause)
+	return true
+}
+
+// Clauses returns all stored clauses
+func (db *ConflictClauseDB) Clauses() []*ConflictClause {
+	return db.clauses
+}
+
+// Size returns the number of stored clauses
+func (db *ConflictClauseDB) Size() int {
+	return len(db.clauses)
+}
+
 // TestGenerationConfig with additional options
 type TestGenerationConfig struct {
 	MaxDecisions           int
@@ -133,11 +250,52 @@ type TestGenerationConfig struct {
 	PreferredHeadLines     []*circuit.Signal
 	BacktraceStrategy      BacktraceStrategy
 	PropagationStrategy    PropagationStrategy
+	SATConflictLimit       int  // Conflict limit of the SAT fallback, zero means no limit
+	UseSATFallback         bool // Route aborted faults to the SAT engine
+	UseStaticLearning      bool // Learn indirect implications before test generation
+	LearnedImplications    *LearnedImplicationTable
+	UseConflictLearning    bool              // Learn conflict clauses when FAN backtracks
+	ShareConflictClauses   bool              // Carry global conflict clauses across faults
+	ConflictClauses        *ConflictClauseDB // Clauses shared across faults, nil if not shared
+	UseBackjumping         bool              // Jump back to the deepest decision a conflict depends on
+	SearchOrder            SearchOrder       // Order in which FAN explores its decision tree
+	RestartInterval        int               // Backtracks before the first restart, doubled after each
+	DecisionHeuristic      DecisionHeuristicKind
+	HeuristicSeed          int64   // Seed of the random decision heuristic
+	RandomPatterns         int     // Random patterns simulated before test generation, zero disables
+	RandomPatternSeed      int64   // Seed of the random patterns
+	SkipResistantFaults    bool    // Leave faults predicted random-pattern resistant to test generation
+	ResistanceThreshold    float64 // Detection probability below which a fault is resistant, zero means one over RandomPatterns
+	FullScan               bool    // Generate tests for sequential circuits on their combinational core
+	MaxTimeFrames          int     // Time frames sequential test generation unrolls a circuit up to without full scan
+	LaunchMode             LaunchMode
 }
 
 // Add strategy enums
 type BacktraceStrategy int
 type PropagationStrategy int
+type SearchOrder int
+type DecisionHeuristicKind int
+type LaunchMode int
+
+const (
+	DEPTH_FIRST_SEARCH         SearchOrder = iota
+	LIMITED_DISCREPANCY_SEARCH             // Allow k alternative values per path, k = 0, 1, ...
+	RESTART_SEARCH                         // Start over with another first decision after a growing number of backtracks
+)
+
+const (
+	BACKTRACE_ORDER_HEURISTIC DecisionHeuristicKind = iota // Order of the backtrace objectives
+	SCOAP_HEURISTIC                                        // Lowest controllability of the value first
+	FANOUT_HEURISTIC                                       // Most fanout branches first
+	ACTIVITY_HEURISTIC                                     // VSIDS-like, lines in recent conflicts first
+	RANDOM_HEURISTIC                                       // Random order from HeuristicSeed
+)
+
+const (
+	LAUNCH_ON_CAPTURE LaunchMode = iota // Transition tests launch with a functional capture after the scan load
+	LAUNCH_ON_SHIFT                     // Transition tests launch with the last shift of the scan load
+)
 
 const (
 	STATIC_BACKTRACE BacktraceStrategy = iota
@@ -157,6 +315,12 @@ type TestGenerationStats struct {
 	Backtracks                   int
 	Implications                 int
 	BacktraceCount               int
+	XPathBacktracks              int // Backtracks caused by a D-frontier without X-path
+	LearnedImplications          int // Values assigned from learned implications
+	LearnedClauses               int // Conflict clauses learned from inconsistencies
+	ClausePrunes                 int // Backtracks caused by a violated conflict clause
+	Backjumps                    int // Backtracks that skipped decisions unrelated to the conflict
+	Restarts                     int // Searches started over by the search order
 	ExecutionTime                time.Duration
 	MaxDecisionLevel             int
 	SuccessRate                  float64
@@ -195,6 +359,7 @@ var (
 	ErrInconsistency = &testError{"Value inconsistency detected", 3}
 	ErrNoSolution    = &testError{"No solution exists", 4}
 	ErrTimeout       = &testError{"Time limit exceeded", 5}
+	ErrFrameLimit    = &testError{"No test within the time frame limit", 6}
 )
 
 // Enhanced constructor functions
@@ -219,6 +384,14 @@ func NewTestGenerationConfig() *TestGenerationConfig {
 		TimeLimit:              time.Minute * 5,
 		BacktraceStrategy:      DYNAMIC_BACKTRACE,
 		PropagationStrategy:    BIDIRECTIONAL_PROPAGATION,
+		SATConflictLimit:       100000,
+		UseSATFallback:         true,
+		UseConflictLearning:    true,
+		UseBackjumping:         true,
+		SearchOrder:            DEPTH_FIRST_SEARCH,
+		RestartInterval:        100,
+		SkipResistantFaults:    true,
+		MaxTimeFrames:          4,
 	}
 }
 
@@ -249,7 +422,7 @@ func calculateObjectivePriority(signal *circuit.Signal, value circuit.SignalValu
 
 func estimateObjectiveCost(signal *circuit.Signal, value circuit.SignalValue) int {
 	// Estimate cost based on controllability
-	cost := signal.Controllability
+	cost := signal.Controllability(value)
 	if signal.FanIn != nil {
 		cost += len(signal.FanIn.Inputs) * 2
 	}
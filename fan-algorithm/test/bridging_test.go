@@ -0,0 +1,62 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/atpg"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+func TestBridgingFaults(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	pairs, err := atpg.ReadBridgingPairs(c, strings.NewReader(`# adjacent nets
+1 2
+3, 6
+6 7
+7 9
+8 11
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := atpg.ReadBridgingPairs(c, strings.NewReader("1 12\n")); err == nil {
+		t.Error("Expected an error for an unknown signal")
+	}
+
+	faults := atpg.BridgingFaults(pairs, atpg.WIRED_AND, atpg.WIRED_OR, atpg.DOMINANT)
+	report := atpg.NewDriver(c, types.NewTestGenerationConfig()).RunBridgingFaults(faults)
+	if len(report.Results) != 4*len(pairs) {
+		t.Fatalf("Expected %d faults, got %d", 4*len(pairs), len(report.Results))
+	}
+
+	// Exhaustive patterns detect exactly the faults with a test
+	fs := atpg.NewFaultSimulator(c)
+	patterns := make([]map[*circuit.Signal]circuit.SignalValue, 0, 32)
+	for v := 0; v < 32; v++ {
+		pattern := make(map[*circuit.Signal]circuit.SignalValue)
+		for i, input := range c.PrimaryInputs {
+			pattern[input] = circuit.SignalValue(v >> i & 1)
+		}
+		patterns = append(patterns, pattern)
+	}
+	detectable := make(map[atpg.BridgingFault]bool)
+	for _, fault := range fs.BridgingCoverage(patterns, faults) {
+		detectable[fault] = true
+	}
+	for _, result := range report.Results {
+		switch {
+		case result.Status == atpg.DETECTED && !fs.DetectsBridge(result.Result.TestPattern, result.Fault):
+			t.Errorf("Test for %s does not detect it", result.Fault)
+		case result.Status != atpg.DETECTED && detectable[result.Fault]:
+			t.Errorf("Expected a test for %s", result.Fault)
+		case result.Status == atpg.ABORTED && !result.Fault.IsFeedback():
+			t.Errorf("Expected %s without feedback to be decided", result.Fault)
+		}
+	}
+	if report.Detected != len(detectable) {
+		t.Errorf("Expected %d detected faults, got %d", len(detectable), report.Detected)
+	}
+}
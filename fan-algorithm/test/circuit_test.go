@@ -2,6 +2,7 @@ package test
 
 import (
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
 	"testing"
 )
 
@@ -24,3 +25,148 @@ func TestCircuitStructure(t *testing.T) {
 		t.Errorf("Circuit should have at least one fanout point")
 	}
 }
+
+func TestBoundAndHeadLines(t *testing.T) {
+	c := examples.CreateC17Circuit()
+
+	// Lines downstream of fanout points 3, 7 and 8 are all bound
+	for _, id := range []string{"7", "8", "9", "10", "11"} {
+		signal, _ := c.GetSignalByID(id)
+		if !signal.IsBound {
+			t.Errorf("Signal %s should be bound", id)
+		}
+	}
+
+	// Head lines are exactly the free lines feeding bound lines
+	expected := map[string]bool{"3": true, "4": true, "5": true, "6": true}
+	if len(c.HeadLines) != len(expected) {
+		t.Errorf("Expected %d head lines, got %d", len(expected), len(c.HeadLines))
+	}
+	for _, hl := range c.HeadLines {
+		if !expected[hl.ID] || hl.IsBound {
+			t.Errorf("Unexpected head line %s", hl.ID)
+		}
+	}
+}
+
+func TestFreeRegions(t *testing.T) {
+	c := examples.CreateC17Circuit()
+
+	head, _ := c.GetSignalByID("6")
+	region := c.FreeRegionOf(head)
+	if len(region.Lines) != 3 || len(region.PrimaryInputs) != 2 {
+		t.Errorf("Free region of 6 should hold 3 lines and 2 inputs, got %d and %d",
+			len(region.Lines), len(region.PrimaryInputs))
+	}
+
+	in1, _ := c.GetSignalByID("1")
+	if c.HeadLineOf(in1) != head {
+		t.Errorf("Head line of 1 should be 6")
+	}
+	if len(c.FreeRegions()) != len(c.HeadLines) {
+		t.Errorf("Expected one free region per head line")
+	}
+}
+
+func TestDominators(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	dt := c.ComputeDominators()
+
+	in1, _ := c.GetSignalByID("1")
+	n8, _ := c.GetSignalByID("8")
+	dominators := dt.Dominators(in1)
+	if len(dominators) != 3 || dominators[2] != n8 {
+		t.Errorf("Expected dominators 1, 6, 8 for signal 1, got %d", len(dominators))
+	}
+
+	// Signal 7 reaches both outputs through disjoint paths
+	n7, _ := c.GetSignalByID("7")
+	if len(dt.Dominators(n7)) != 1 {
+		t.Errorf("Signal 7 should only dominate itself")
+	}
+	if !dt.Dominates(n8, in1) || dt.Dominates(n8, n7) {
+		t.Errorf("Unexpected dominance relation for signal 8")
+	}
+
+	n6, _ := c.GetSignalByID("6")
+	common := dt.CommonDominators([]*circuit.Signal{in1, n6})
+	if len(common) != 2 || common[0] != n6 {
+		t.Errorf("Expected common dominators 6, 8")
+	}
+}
+
+func TestSCOAP(t *testing.T) {
+	c := examples.CreateReconvergentCircuit()
+	c.ComputeSCOAP()
+
+	// id: CC0, CC1, CO
+	expected := map[string][3]int{
+		"a":   {1, 1, 7},
+		"b":   {1, 1, 7},
+		"d":   {1, 1, 5},
+		"x":   {2, 3, 5},
+		"z":   {5, 4, 2},
+		"out": {2, 6, 0},
+	}
+	for id, measures := range expected {
+		signal, _ := c.GetSignalByID(id)
+		if got := [3]int{signal.CC0, signal.CC1, signal.CO}; got != measures {
+			t.Errorf("Signal %s: expected CC0, CC1, CO %v, got %v", id, measures, got)
+		}
+	}
+
+	// Setting d=0 is cheaper than z=0 for the output gate
+	d, _ := c.GetSignalByID("d")
+	out, _ := c.GetSignalByID("out")
+	if easiest := out.FanIn.GetEasiestControllingInput(); easiest != d {
+		t.Errorf("Expected d as the easiest controlling input, got %s", easiest.ID)
+	}
+}
+
+func TestTrailUndo(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	trail := c.Trail()
+	in1, _ := c.GetSignalByID("1")
+	in2, _ := c.GetSignalByID("2")
+	n8, _ := c.GetSignalByID("8")
+
+	in1.Assign(circuit.ONE)
+	snapshot := trail.Len()
+
+	trail.NewLevel()
+	in2.Assign(circuit.ZERO)
+	n8.Assign(circuit.ONE)
+	trail.NewLevel()
+	in2.Assign(circuit.ONE)
+	if trail.Level() != 2 || trail.Len() != 4 {
+		t.Fatalf("Expected 4 assignments on 2 levels, got %d on %d", trail.Len(), trail.Level())
+	}
+
+	// Undoing the second level keeps the values of the first
+	trail.BacktrackTo(1)
+	if in2.Value != circuit.ZERO || n8.Value != circuit.ONE || trail.Level() != 1 {
+		t.Error("Backtracking should only undo the assignments of the upper level")
+	}
+
+	trail.UndoTo(snapshot)
+	if in1.Value != circuit.ONE || in2.Value != circuit.X || n8.Value != circuit.X {
+		t.Error("Restoring a snapshot should undo every later assignment")
+	}
+	if trail.Level() != 0 {
+		t.Error("Levels opened after the snapshot should be dropped")
+	}
+}
+
+func TestTrailAttachesAppendedSignals(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	trail := c.Trail()
+	extra := circuit.NewSignal("extra")
+	c.Signals = append(c.Signals, extra)
+
+	c.Trail().NewLevel()
+	extra.Assign(circuit.ONE)
+	trail.BacktrackTo(0)
+	if extra.Value != circuit.X {
+		t.Error("A signal appended after the trail was created should be restored")
+	}
+}
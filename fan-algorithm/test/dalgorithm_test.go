@@ -0,0 +1,95 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/algorithm"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+)
+
+func TestGateCubes(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	gate := c.Gates[0] // Two-input AND
+
+	// Two controlling cubes and one all non-controlling cube
+	if cover := algorithm.SingularCover(gate); len(cover) != 3 {
+		t.Errorf("Expected 3 singular cubes for 2-input AND, got %d", len(cover))
+	}
+	// One cube per input and polarity
+	if cubes := algorithm.PropagationDCubes(gate); len(cubes) != 4 {
+		t.Errorf("Expected 4 propagation D-cubes for 2-input AND, got %d", len(cubes))
+	}
+	// Stuck-at-0 is activated by all inputs at 1
+	failure := algorithm.PrimitiveDCubesOfFailure(gate, circuit.ZERO)
+	if len(failure) != 1 || failure[0].Output() != circuit.D {
+		t.Errorf("Expected a single D-cube of failure with output D")
+	}
+}
+
+func TestDAlgorithmMatchesFAN(t *testing.T) {
+	c := examples.CreateC17Circuit()
+
+	for _, signal := range c.Signals {
+		for _, faultValue := range []circuit.SignalValue{circuit.ZERO, circuit.ONE} {
+			fan := algorithm.FAN(c, signal, faultValue)
+			dalg := algorithm.DAlgorithm(c, signal, faultValue)
+			if fan.Success != dalg.Success {
+				t.Errorf("Fault %s stuck-at-%d: FAN success=%v, D-algorithm success=%v",
+					signal.ID, faultValue, fan.Success, dalg.Success)
+			}
+			t.Logf("Fault %s stuck-at-%d: FAN decisions=%d, D-algorithm decisions=%d",
+				signal.ID, faultValue, fan.Stats.Decisions, dalg.Stats.Decisions)
+		}
+	}
+}
+
+func TestDAlgorithmReconvergentEffects(t *testing.T) {
+	// The fault effect on i0 reaches n1 directly and through n0
+	c := circuit.NewCircuit()
+	i0, i1, i2 := circuit.NewSignal("i0"), circuit.NewSignal("i1"), circuit.NewSignal("i2")
+	n0, n1 := circuit.NewSignal("n0"), circuit.NewSignal("n1")
+	for _, input := range []*circuit.Signal{i0, i1, i2} {
+		c.AddPrimaryInput(input)
+	}
+	i0.AddFanout(n0)
+	i0.AddFanout(n1)
+	i1.AddFanout(n0)
+	i2.AddFanout(n1)
+	n0.AddFanout(n1)
+	c.AddGate(circuit.NewGate("g0", circuit.AND, []*circuit.Signal{i0, i1}, n0, c))
+	c.AddGate(circuit.NewGate("g1", circuit.AND, []*circuit.Signal{i2, n0, i0}, n1, c))
+	c.AddPrimaryOutput(n1)
+	c.IdentifyBoundAndHeadLines()
+
+	result := algorithm.DAlgorithm(c, i0, circuit.ZERO)
+	if !result.Success {
+		t.Fatal("Failed to propagate both fault effects through n1")
+	}
+	for _, input := range c.PrimaryInputs {
+		if result.TestPattern[input] != circuit.ONE {
+			t.Errorf("Expected input %s=1, got %d", input.ID, result.TestPattern[input])
+		}
+	}
+}
+
+func TestDAlgorithmBacktrack(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	trail := c.Trail()
+	result := algorithm.DAlgorithm(c, c.Gates[0].Output, circuit.ZERO)
+	if !result.Success || len(result.Decisions) == 0 {
+		t.Fatal("Expected a test found through cube decisions")
+	}
+
+	// Every value of the search was recorded, undoing the trail restores the reset circuit
+	decided := result.Decisions[0].Signal
+	if decided.Value == circuit.X || trail.Len() == 0 {
+		t.Fatal("Expected the decisions of the D-algorithm on the trail")
+	}
+	trail.UndoTo(0)
+	for _, signal := range c.Signals {
+		if signal.Value != circuit.X {
+			t.Errorf("Signal %s keeps value %d after backtracking", signal.ID, signal.Value)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package test
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/algorithm"
 	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/strategy"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
 	"testing"
 )
 
@@ -80,7 +85,358 @@ func TestUniqueSensitization(t *testing.T) {
 	}
 }
 
-// Helper functions
+func TestHeadLineJustification(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	signal, _ := c.GetSignalByID("10")
+
+	result := algorithm.FAN(c, signal, circuit.ONE)
+	if !result.Success {
+		t.Fatal("Failed to find test pattern for stuck-at-1 fault at 10")
+	}
+
+	// Decisions are restricted to head lines and primary inputs
+	for _, decision := range result.Decisions {
+		if !decision.Signal.IsHead && decision.Signal.FanIn != nil {
+			t.Errorf("Decision on %s is neither a head line nor a primary input", decision.Signal.ID)
+		}
+	}
+
+	// Known change in behavior: with SCOAP input 3 is easier to set to 0
+	// than head line 6, so 3=0 is decided and the free region below 6 is
+	// left unassigned. TestHeadLineJustificationAfterPropagation still
+	// justifies it.
+	if len(result.Decisions) != 1 || result.Decisions[0].Signal.ID != "3" ||
+		result.Decisions[0].Value != circuit.ZERO {
+		t.Errorf("Expected the single decision 3=0, got %d decisions", len(result.Decisions))
+	}
+	for _, assignment := range result.Implications {
+		if assignment.Signal.ID == "1" {
+			t.Error("Primary input 1 should be left to the free region")
+		}
+	}
+}
+
+func TestHeadLineJustificationAfterPropagation(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	signal, _ := c.GetSignalByID("8")
+
+	result := algorithm.FAN(c, signal, circuit.ONE)
+	if !result.Success {
+		t.Fatal("Failed to find test pattern for stuck-at-1 fault at 8")
+	}
+
+	// Decisions are restricted to head lines and primary inputs
+	for _, decision := range result.Decisions {
+		if !decision.Signal.IsHead && decision.Signal.FanIn != nil {
+			t.Errorf("Decision on %s is neither a head line nor a primary input", decision.Signal.ID)
+		}
+	}
+
+	// The effect of 8 is propagated to output 10 before the free region
+	// below head line 6 is justified
+	justified := false
+	for _, assignment := range result.Implications {
+		if assignment.Signal.ID == "1" && assignment.Reason == types.IMPLICATION {
+			justified = true
+		}
+	}
+	if !justified {
+		t.Error("Primary input 1 should be assigned by head line justification")
+	}
+}
+
+func TestDominatorUniqueSensitization(t *testing.T) {
+	c := examples.CreateFanTestCircuit()
+	signal, _ := c.GetSignalByID("in3")
+
+	result := algorithm.FAN(c, signal, circuit.ZERO)
+	if !result.Success {
+		t.Fatal("Failed to find test pattern for stuck-at-0 fault at in3")
+	}
+
+	// n1 is the off-path input of dominator n2 and must be non-controlling
+	found := false
+	for _, assignment := range result.Implications {
+		if assignment.Reason == types.UNIQUE_SENSITIZATION {
+			if assignment.Signal.ID != "n1" || assignment.Value != circuit.ZERO {
+				t.Errorf("Unexpected unique sensitization %s=%s",
+					assignment.Signal.ID, valueToString(assignment.Value))
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected unique sensitization of n1")
+	}
+}
+
+func TestXPathCheck(t *testing.T) {
+	// out = AND(AND(AND(p, q), b), NOT(p)) is constant 0, so the fault effect
+	// on f is blocked once p=1 is implied
+	c := circuit.NewCircuit()
+	p := circuit.NewSignal("p")
+	q := circuit.NewSignal("q")
+	b := circuit.NewSignal("b")
+	f := circuit.NewSignal("f")
+	z := circuit.NewSignal("z")
+	n1 := circuit.NewSignal("n1")
+	out := circuit.NewSignal("out")
+
+	c.AddPrimaryInput(p)
+	c.AddPrimaryInput(q)
+	c.AddPrimaryInput(b)
+	c.AddPrimaryOutput(out)
+	c.AddGate(circuit.NewGate("g1", circuit.AND, []*circuit.Signal{p, q}, f, c))
+	c.AddGate(circuit.NewGate("g2", circuit.NOT, []*circuit.Signal{p}, z, c))
+	c.AddGate(circuit.NewGate("g3", circuit.AND, []*circuit.Signal{f, b}, n1, c))
+	c.AddGate(circuit.NewGate("g4", circuit.AND, []*circuit.Signal{n1, z}, out, c))
+	p.AddFanout(f)
+	p.AddFanout(z)
+	q.AddFanout(f)
+	b.AddFanout(n1)
+	f.AddFanout(n1)
+	n1.AddFanout(out)
+	z.AddFanout(out)
+	c.IdentifyBoundAndHeadLines()
+
+	result := algorithm.FAN(c, f, circuit.ZERO)
+	if result.Success {
+		t.Fatal("Stuck-at-0 fault at f is redundant and should not be detected")
+	}
+	if result.Stats.XPathBacktracks == 0 {
+		t.Error("Blocked D-frontier should be detected by the X-path check")
+	}
+}
+
+func TestBackjumping(t *testing.T) {
+	c := createBackjumpCircuit()
+	f, _ := c.GetSignalByID("f")
+
+	chronological := types.NewTestGenerationConfig()
+	chronological.UseBackjumping = false
+	expected := algorithm.FANWithConfig(c, f, circuit.ZERO, chronological)
+
+	result := algorithm.FANWithConfig(c, f, circuit.ZERO, types.NewTestGenerationConfig())
+	if !result.Success || !expected.Success {
+		t.Fatal("Failed to find test pattern for stuck-at-0 fault at f")
+	}
+	if result.Stats.Backjumps == 0 {
+		t.Error("Expected the search to skip a decision unrelated to the conflict")
+	}
+	if result.Stats.Backtracks >= expected.Stats.Backtracks {
+		t.Errorf("Backjumping took %d backtracks, chronological backtracking %d",
+			result.Stats.Backtracks, expected.Stats.Backtracks)
+	}
+}
+
+func TestBackjumpingOverUniqueSensitization(t *testing.T) {
+	// c=1 inverts the effect of a on x and e=1 copies it onto y, the two cancel
+	// at z. Whatever is decided on g then, only w is left on the D-frontier and
+	// unique sensitization requires both h and NOT h. The conflict depends on
+	// c and e through the D-frontier, not only on the decision on g.
+	c, err := circuit.ReadBench(strings.NewReader(`INPUT(a)
+INPUT(c)
+INPUT(e)
+INPUT(g)
+INPUT(h)
+OUTPUT(z)
+OUTPUT(w)
+x = XOR(c, a)
+y = AND(a, e)
+z = NOR(x, y, g)
+hn = NOT(h)
+w = AND(hn, a, h)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := c.GetSignalByID("a")
+
+	for _, learning := range []bool{false, true} {
+		config := types.NewTestGenerationConfig()
+		config.UseConflictLearning = learning
+		if result := algorithm.FANWithConfig(c, a, circuit.ZERO, config); !result.Success {
+			t.Errorf("Backjumping with learning %v failed to test a stuck-at-0: %v", learning, result.Error)
+		}
+	}
+}
+
+func TestSearchOrders(t *testing.T) {
+	c := createBackjumpCircuit()
+	f, _ := c.GetSignalByID("f")
+
+	orders := []types.SearchOrder{
+		types.DEPTH_FIRST_SEARCH,
+		types.LIMITED_DISCREPANCY_SEARCH,
+		types.RESTART_SEARCH,
+	}
+	for _, order := range orders {
+		config := types.NewTestGenerationConfig()
+		config.SearchOrder = order
+		config.RestartInterval = 1
+
+		result, tree := algorithm.FANWithTree(c, f, circuit.ZERO, config)
+		if !result.Success {
+			t.Errorf("Search order %d failed to find a test pattern", order)
+			continue
+		}
+		// The tree keeps the failed branches next to the final path
+		if tree.Size() <= len(result.Decisions) {
+			t.Errorf("Search order %d: expected failed branches in the tree, got %d nodes for %d decisions",
+				order, tree.Size(), len(result.Decisions))
+		}
+		if order != types.DEPTH_FIRST_SEARCH && result.Stats.Restarts == 0 {
+			t.Errorf("Search order %d should have restarted the search", order)
+		}
+
+		var dot strings.Builder
+		if err := tree.WriteDOT(&dot); err != nil || !strings.Contains(dot.String(), "style=dashed") {
+			t.Errorf("Search order %d: expected failed branches in the exported tree", order)
+		}
+	}
+}
+
+func TestRestartExploresNewSubtree(t *testing.T) {
+	c := createBackjumpCircuit()
+	f, _ := c.GetSignalByID("f")
+	config := types.NewTestGenerationConfig()
+	config.SearchOrder = types.RESTART_SEARCH
+	config.RestartInterval = 1
+
+	result, tree := algorithm.FANWithTree(c, f, circuit.ZERO, config)
+	if !result.Success || result.Stats.Restarts == 0 {
+		t.Fatalf("Expected a test found after a restart, got %d restarts", result.Stats.Restarts)
+	}
+	// The first search decides c, the restart begins with another candidate
+	first := tree.Root.Children
+	if len(first) < 2 || first[0].Signal == first[len(first)-1].Signal {
+		t.Error("Expected the restart to start with a different first decision")
+	}
+}
+
+func TestDecisionHeuristics(t *testing.T) {
+	kinds := []types.DecisionHeuristicKind{
+		types.BACKTRACE_ORDER_HEURISTIC,
+		types.SCOAP_HEURISTIC,
+		types.FANOUT_HEURISTIC,
+		types.ACTIVITY_HEURISTIC,
+		types.RANDOM_HEURISTIC,
+	}
+	for _, kind := range kinds {
+		config := types.NewTestGenerationConfig()
+		config.DecisionHeuristic = kind
+		config.HeuristicSeed = 7
+
+		c := createBackjumpCircuit()
+		f, _ := c.GetSignalByID("f")
+		if result := algorithm.FANWithConfig(c, f, circuit.ZERO, config); !result.Success {
+			t.Errorf("Heuristic %d failed to test f stuck-at-0: %v", kind, result.Error)
+		}
+
+		c17 := examples.CreateC17Circuit()
+		for _, signal := range c17.Signals {
+			if result := algorithm.FANWithConfig(c17, signal, circuit.ONE, config); !result.Success {
+				t.Errorf("Heuristic %d failed to test %s stuck-at-1 in C17", kind, signal.ID)
+			}
+		}
+	}
+
+	// The same seed gives the same decisions
+	c := createBackjumpCircuit()
+	f, _ := c.GetSignalByID("f")
+	decisions := func(seed int64) string {
+		generator := &algorithm.FANGenerator{
+			Config:    types.NewTestGenerationConfig(),
+			Heuristic: strategy.NewRandomHeuristic(seed),
+		}
+		var path strings.Builder
+		for _, decision := range generator.Generate(c, f, circuit.ZERO).Decisions {
+			fmt.Fprintf(&path, "%s=%d ", decision.Signal.ID, decision.Value)
+		}
+		return path.String()
+	}
+	if decisions(3) != decisions(3) {
+		t.Error("Random heuristic is not reproducible from its seed")
+	}
+
+	// Conflicts make their lines preferred
+	activity := strategy.NewActivityHeuristic()
+	d, _ := c.GetSignalByID("d")
+	e1, _ := c.GetSignalByID("e1")
+	activity.OnConflict([]*circuit.Signal{d, e1})
+	activity.OnConflict([]*circuit.Signal{e1})
+	ranked := activity.Rank(c, []strategy.Candidate{{Signal: d, Value: circuit.ONE}, {Signal: e1, Value: circuit.ONE}})
+	if ranked[0].Signal != e1 || activity.Activity(e1) <= activity.Activity(d) {
+		t.Errorf("Expected e1 to be ranked first after its conflicts, got %s", ranked[0].Signal.ID)
+	}
+}
+
+// testGate describes a gate of a circuit built by buildCircuit
+type testGate struct {
+	output string
+	kind   circuit.GateType
+	inputs []string
+}
+
+// buildCircuit creates a circuit from signal names, gates must be listed in topological order
+func buildCircuit(inputs, outputs []string, gates []testGate) *circuit.Circuit {
+	c := circuit.NewCircuit()
+	signals := make(map[string]*circuit.Signal)
+	for _, id := range inputs {
+		signals[id] = circuit.NewSignal(id)
+		c.AddPrimaryInput(signals[id])
+	}
+	for i, g := range gates {
+		out := circuit.NewSignal(g.output)
+		signals[g.output] = out
+		ins := make([]*circuit.Signal, len(g.inputs))
+		for j, id := range g.inputs {
+			ins[j] = signals[id]
+			ins[j].AddFanout(out)
+		}
+		c.AddGate(circuit.NewGate(fmt.Sprintf("g%d", i), g.kind, ins, out, c))
+	}
+	for _, id := range outputs {
+		c.AddPrimaryOutput(signals[id])
+	}
+	c.IdentifyBoundAndHeadLines()
+	return c
+}
+
+// createBackjumpCircuit builds f = AND(v, u, x), testing f stuck-at-0
+// implies v, u and x at 1. u = OR(AND(t, OR(cn, tn)), AND(tn, OR(cn, t)))
+// equals NOT c, but implication only notices once t is decided. FAN first
+// sets c=1 for v = OR(c, d), then e1=1 for x = OR(e1, e2), e1 also drives y
+// so x is no free line. Both values of t fail because of c=1 alone, so the
+// search jumps back to c over the unrelated decision on e1. d drives a copy
+// of the network of c to z, c and d are equally easy to control by any
+// measure and the backtrace of v always picks c.
+func createBackjumpCircuit() *circuit.Circuit {
+	return buildCircuit(
+		[]string{"c", "d", "e1", "e2", "t"},
+		[]string{"f", "y", "z"},
+		[]testGate{
+			{"cn", circuit.NOT, []string{"c"}},
+			{"tn", circuit.NOT, []string{"t"}},
+			{"s1", circuit.OR, []string{"cn", "tn"}},
+			{"s2", circuit.OR, []string{"cn", "t"}},
+			{"p", circuit.AND, []string{"t", "s1"}},
+			{"q", circuit.AND, []string{"tn", "s2"}},
+			{"u", circuit.OR, []string{"p", "q"}},
+			{"dn", circuit.NOT, []string{"d"}},
+			{"r1", circuit.OR, []string{"dn", "tn"}},
+			{"r2", circuit.OR, []string{"dn", "t"}},
+			{"m1", circuit.AND, []string{"t", "r1"}},
+			{"m2", circuit.AND, []string{"tn", "r2"}},
+			{"w", circuit.OR, []string{"m1", "m2"}},
+			{"z", circuit.NOT, []string{"w"}},
+			{"v", circuit.OR, []string{"c", "d"}},
+			{"x", circuit.OR, []string{"e1", "e2"}},
+			{"y", circuit.NOT, []string{"e1"}},
+			{"f", circuit.AND, []string{"v", "u", "x"}},
+		})
+}
+
 func valueToString(v circuit.SignalValue) string {
 	switch v {
 	case circuit.ZERO:
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/algorithm"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/atpg"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+func TestStaticLearning(t *testing.T) {
+	c := examples.CreateReconvergentCircuit()
+	table := algorithm.StaticLearning(c)
+
+	a, _ := c.GetSignalByID("a")
+	z, _ := c.GetSignalByID("z")
+
+	// a=0 implies z=0, so z=1 must imply a=1
+	found := false
+	for _, implication := range table.Lookup(z, circuit.ONE) {
+		if implication.Target == a && implication.TargetValue == circuit.ONE {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected learned implication z=1 -> a=1, table holds %d entries", table.Size())
+	}
+}
+
+func TestLearnedConstants(t *testing.T) {
+	c := createTautologyCircuit()
+	table := algorithm.StaticLearning(c)
+
+	// out=1 fails implication through n0 and NOT(n0), so out is constant zero
+	out, _ := c.GetSignalByID("out")
+	found := false
+	for _, constant := range table.Constants() {
+		if constant.Signal == out && constant.Value == circuit.ZERO {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected out to be learned constant zero, learned %d constants", len(table.Constants()))
+	}
+}
+
+func TestLearnedImplicationsFire(t *testing.T) {
+	c := examples.CreateReconvergentCircuit()
+	z, _ := c.GetSignalByID("z")
+
+	config := types.NewTestGenerationConfig()
+	config.LearnedImplications = algorithm.StaticLearning(c)
+
+	result := algorithm.FANWithConfig(c, z, circuit.ZERO, config)
+	if !result.Success {
+		t.Fatal("Failed to find test pattern for stuck-at-0 fault at z")
+	}
+	if result.Stats.LearnedImplications == 0 {
+		t.Error("Activating z=1 should fire the learned implication a=1")
+	}
+
+	// Implication on a fresh copy of the circuit consults the same table
+	fresh := examples.CreateReconvergentCircuit()
+	freshZ, _ := fresh.GetSignalByID("z")
+	freshZ.SetValue(circuit.ONE)
+	implication := algorithm.ImplicationWithLearning(fresh, types.Assignment{Signal: freshZ, Value: circuit.ONE},
+		algorithm.StaticLearning(fresh))
+	if implication.Stats.LearnedImplications == 0 {
+		t.Error("Implication should consult the learned implication table")
+	}
+}
+
+// createTautologyCircuit builds out = NOT(OR(NOT(n0), i1, n0)) with n0 = OR(i0, i2, i3),
+// so out is constant zero and most faults are redundant
+func createTautologyCircuit() *circuit.Circuit {
+	c := circuit.NewCircuit()
+	inputs := make([]*circuit.Signal, 4)
+	for i := range inputs {
+		inputs[i] = circuit.NewSignal(fmt.Sprintf("i%d", i))
+		c.AddPrimaryInput(inputs[i])
+	}
+	n0 := circuit.NewSignal("n0")
+	n1 := circuit.NewSignal("n1")
+	n2 := circuit.NewSignal("n2")
+	out := circuit.NewSignal("out")
+	c.AddPrimaryOutput(out)
+
+	c.AddGate(circuit.NewGate("g0", circuit.OR, []*circuit.Signal{inputs[0], inputs[2], inputs[3]}, n0, c))
+	c.AddGate(circuit.NewGate("g1", circuit.NOT, []*circuit.Signal{n0}, n1, c))
+	c.AddGate(circuit.NewGate("g2", circuit.OR, []*circuit.Signal{n1, inputs[1], n0}, n2, c))
+	c.AddGate(circuit.NewGate("g3", circuit.NOT, []*circuit.Signal{n2}, out, c))
+
+	inputs[0].AddFanout(n0)
+	inputs[2].AddFanout(n0)
+	inputs[3].AddFanout(n0)
+	n0.AddFanout(n1)
+	n0.AddFanout(n2)
+	n1.AddFanout(n2)
+	inputs[1].AddFanout(n2)
+	n2.AddFanout(out)
+
+	c.IdentifyBoundAndHeadLines()
+	return c
+}
+
+func TestSharedConflictClauses(t *testing.T) {
+	c := createTautologyCircuit()
+
+	baseline := types.NewTestGenerationConfig()
+	baseline.UseSATFallback = false
+	expected := atpg.NewDriver(c, baseline).Run(atpg.AllFaults(c))
+
+	config := types.NewTestGenerationConfig()
+	config.UseSATFallback = false
+	config.ShareConflictClauses = true
+	report := atpg.NewDriver(c, config).Run(atpg.AllFaults(c))
+
+	if config.ConflictClauses == nil || config.ConflictClauses.Size() == 0 {
+		t.Fatal("Expected conflict clauses to be shared across faults")
+	}
+	for _, clause := range config.ConflictClauses.Clauses() {
+		if !clause.Global {
+			t.Error("Only clauses valid for every fault may be shared")
+		}
+	}
+	if report.Detected != expected.Detected || report.Redundant != expected.Redundant {
+		t.Errorf("Learning changed the outcome: %d/%d detected/redundant, expected %d/%d",
+			report.Detected, report.Redundant, expected.Detected, expected.Redundant)
+	}
+}
@@ -0,0 +1,93 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/atpg"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+func TestMultipleFaults(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	driver := atpg.NewDriver(c, types.NewTestGenerationConfig())
+	faults := atpg.DoubleFaults(atpg.AllFaults(c))
+	report, err := driver.RunMultipleFaults(faults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Exhaustive patterns detect exactly the faults with a test
+	fs := atpg.NewFaultSimulator(c)
+	patterns := make([]map[*circuit.Signal]circuit.SignalValue, 0, 32)
+	for v := 0; v < 32; v++ {
+		pattern := make(map[*circuit.Signal]circuit.SignalValue)
+		for i, input := range c.PrimaryInputs {
+			pattern[input] = circuit.SignalValue(v >> i & 1)
+		}
+		patterns = append(patterns, pattern)
+	}
+	detectable := make(map[string]bool)
+	for _, fault := range fs.MultipleCoverage(patterns, faults) {
+		detectable[fault.String()] = true
+	}
+	for _, result := range report.Results {
+		switch {
+		case result.Status == atpg.DETECTED && !fs.DetectsMultiple(result.Result.TestPattern, result.Fault):
+			t.Errorf("Test for %s does not detect it", result.Fault)
+		case result.Status != atpg.DETECTED && detectable[result.Fault.String()]:
+			t.Errorf("Expected a test for %s", result.Fault)
+		}
+	}
+	if report.Detected != len(detectable) {
+		t.Errorf("Expected %d detected faults, got %d", len(detectable), report.Detected)
+	}
+
+	// The single fault tests cover no more double faults than have a test
+	tests := make([]map[*circuit.Signal]circuit.SignalValue, 0)
+	for _, result := range driver.Run(atpg.AllFaults(c)).Results {
+		if result.Status == atpg.DETECTED {
+			tests = append(tests, result.Result.TestPattern)
+		}
+	}
+	// Every double fault the single fault tests cover has a test
+	for _, fault := range fs.MultipleCoverage(tests, faults) {
+		if !detectable[fault.String()] {
+			t.Errorf("Single fault tests cover %s, which has no test", fault)
+		}
+	}
+
+	// Two faults on the branches of a reconvergent fanout mask each other
+	x, err := circuit.ReadBench(strings.NewReader("INPUT(a)\nOUTPUT(y)\nb = BUFF(a)\nc = BUFF(a)\ny = XOR(b, c)\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := x.GetSignalByID("b")
+	d, _ := x.GetSignalByID("c")
+	masked := atpg.MultipleFault{Faults: []atpg.Fault{{Site: b, StuckAt: circuit.ZERO}, {Site: d, StuckAt: circuit.ZERO}}}
+	xDriver := atpg.NewDriver(x, types.NewTestGenerationConfig())
+	xReport, err := xDriver.RunMultipleFaults([]atpg.MultipleFault{masked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := xReport.Results[0].Status; status != atpg.REDUNDANT {
+		t.Errorf("Expected %s to be redundant, got %v", masked, status)
+	}
+	if report := xDriver.Run(masked.Faults); report.Detected != 2 {
+		t.Errorf("Expected both single faults to be detected, got %d", report.Detected)
+	}
+}
+
+func TestMultipleFaultsOnOneLine(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	driver := atpg.NewDriver(c, types.NewTestGenerationConfig())
+	site, _ := c.GetSignalByID("10")
+	for _, second := range []circuit.SignalValue{circuit.ZERO, circuit.ONE} {
+		fault := atpg.MultipleFault{Faults: []atpg.Fault{{Site: site, StuckAt: circuit.ZERO}, {Site: site, StuckAt: second}}}
+		if _, err := driver.RunMultipleFaults([]atpg.MultipleFault{fault}); err == nil {
+			t.Errorf("Expected an error for %s", fault)
+		}
+	}
+}
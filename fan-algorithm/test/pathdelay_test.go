@@ -0,0 +1,51 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/atpg"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+func TestPathDelayFaults(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	d := atpg.NewDriver(c, types.NewTestGenerationConfig())
+
+	paths := d.LongestPaths(100)
+	if len(paths) != 11 {
+		t.Fatalf("Expected the 11 paths of c17, got %d", len(paths))
+	}
+	for i := 1; i < len(paths); i++ {
+		if paths[i].Delay > paths[i-1].Delay {
+			t.Errorf("Path %d is longer than path %d", i, i-1)
+		}
+	}
+
+	// Counts checked against every pair of patterns
+	report := d.RunPathDelayFaults(atpg.PathDelayFaults(paths))
+	if report.Robust != 14 || report.NonRobust != 4 || report.Untestable != 4 {
+		t.Errorf("Expected 14 robust, 4 non-robust and 4 untestable faults, got %d, %d and %d",
+			report.Robust, report.NonRobust, report.Untestable)
+	}
+	fs := atpg.NewFaultSimulator(c)
+	for _, result := range report.Results {
+		robust := result.Testability == atpg.ROBUST
+		if (robust || result.Testability == atpg.NON_ROBUST) &&
+			!fs.SensitizesPath(result.Initialization, result.Launch, result.Fault, robust) {
+			t.Errorf("Test for %s does not sensitize the path", result.Fault)
+		}
+	}
+
+	// An annotated delay makes the paths through the gate the longest
+	annotated := paths[len(paths)-1].Gates[0]
+	annotated.Delay = 10
+	longest := d.LongestPaths(1)[0]
+	through := false
+	for _, gate := range longest.Gates {
+		through = through || gate == annotated
+	}
+	if !through || longest.Delay != paths[0].Delay+9 {
+		t.Errorf("Expected the longest path through the annotated gate, got delay %d", longest.Delay)
+	}
+}
@@ -0,0 +1,45 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/algorithm"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+func TestPODEMMatchesFAN(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	config := types.NewTestGenerationConfig()
+	generators := []algorithm.TestGenerator{
+		algorithm.NewFANGenerator(config),
+		algorithm.NewPODEMGenerator(config),
+	}
+
+	for _, signal := range c.Signals {
+		for _, faultValue := range []circuit.SignalValue{circuit.ZERO, circuit.ONE} {
+			fan := generators[0].Generate(c, signal, faultValue)
+			podem := generators[1].Generate(c, signal, faultValue)
+			if fan.Success != podem.Success {
+				t.Errorf("Fault %s stuck-at-%d: FAN success=%v, PODEM success=%v",
+					signal.ID, faultValue, fan.Success, podem.Success)
+			}
+		}
+	}
+}
+
+func TestPODEMDecisionsOnPrimaryInputs(t *testing.T) {
+	c := examples.CreateFanTestCircuit()
+	signal, _ := c.GetSignalByID("n3")
+
+	result := algorithm.PODEM(c, signal, circuit.ZERO)
+	if !result.Success {
+		t.Fatal("PODEM failed to find test pattern for stuck-at-0 fault at n3")
+	}
+	for _, decision := range result.Decisions {
+		if decision.Signal.FanIn != nil {
+			t.Errorf("PODEM decision on internal signal %s", decision.Signal.ID)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/algorithm"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/atpg"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/sat"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+func TestCDCLSolver(t *testing.T) {
+	// (a | b) & (!a | b) & (a | !b) is satisfied only by a = b = 1
+	f := sat.NewCNF()
+	a, b := f.NewVar(), f.NewVar()
+	f.AddClause(a, b)
+	f.AddClause(-a, b)
+	f.AddClause(a, -b)
+
+	solver := sat.NewSolver(f)
+	if solver.Solve() != sat.SATISFIABLE {
+		t.Fatal("Formula should be satisfiable")
+	}
+	if model := solver.Model(); !model[a] || !model[b] {
+		t.Error("Expected a = b = 1")
+	}
+
+	f.AddClause(-a, -b)
+	if sat.NewSolver(f).Solve() != sat.UNSATISFIABLE {
+		t.Error("Formula should be unsatisfiable")
+	}
+}
+
+func TestSATMatchesFAN(t *testing.T) {
+	c := examples.CreateC17Circuit()
+
+	for _, signal := range c.Signals {
+		for _, faultValue := range []circuit.SignalValue{circuit.ZERO, circuit.ONE} {
+			fan := algorithm.FAN(c, signal, faultValue)
+			result := algorithm.SATATPG(c, signal, faultValue)
+			if fan.Success != result.Success {
+				t.Errorf("Fault %s stuck-at-%d: FAN success=%v, SAT success=%v",
+					signal.ID, faultValue, fan.Success, result.Success)
+			}
+		}
+	}
+}
+
+func TestSATProvesRedundancy(t *testing.T) {
+	c := createRedundantCircuit()
+	f, _ := c.GetSignalByID("f")
+
+	result := algorithm.SATATPG(c, f, circuit.ZERO)
+	if result.Success || result.Error != types.ErrNoSolution {
+		t.Error("SAT engine should prove stuck-at-0 at f redundant")
+	}
+}
+
+func TestDriverRoutesAbortedFaults(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	config := types.NewTestGenerationConfig()
+	config.MaxDecisions = 1 // Force FAN to abort on faults needing several decisions
+
+	report := atpg.NewDriver(c, config).Run(atpg.AllFaults(c))
+	if report.FallbackRuns == 0 {
+		t.Error("Expected aborted faults to be routed to the SAT engine")
+	}
+	if report.Aborted != 0 || report.Detected != len(report.Results) {
+		t.Errorf("Expected all faults detected, got %d detected, %d redundant, %d aborted",
+			report.Detected, report.Redundant, report.Aborted)
+	}
+}
+
+func TestDIMACSRoundTrip(t *testing.T) {
+	c := examples.CreateC17Circuit()
+	site, _ := c.GetSignalByID("8")
+
+	var cnfBuf, mapBuf bytes.Buffer
+	if _, err := algorithm.ExportFaultDIMACS(c, site, circuit.ONE, &cnfBuf, &mapBuf); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// Solve the exported formula as an external solver would
+	formula, err := sat.ReadDIMACS(&cnfBuf)
+	if err != nil {
+		t.Fatalf("Could not read DIMACS: %v", err)
+	}
+	solver := sat.NewSolver(formula)
+	var solution bytes.Buffer
+	sat.WriteSolution(&solution, solver.Solve(), solver.Model())
+
+	status, model, err := sat.ReadSolution(&solution, formula.NumVars)
+	if err != nil || status != sat.SATISFIABLE {
+		t.Fatalf("Expected a satisfiable solution, got status %d, error %v", status, err)
+	}
+
+	variables, err := algorithm.ReadVariableMap(c, &mapBuf)
+	if err != nil {
+		t.Fatalf("Could not read variable map: %v", err)
+	}
+	if variables.Site != site || variables.StuckAt != circuit.ONE {
+		t.Error("Variable map should restore the targeted fault")
+	}
+
+	// Propagating from 8 needs 7=1 or 9=1, both require input 3 at 1
+	pattern := variables.PatternFromModel(c, model)
+	in3, _ := c.GetSignalByID("3")
+	if pattern[in3] != circuit.ONE {
+		t.Errorf("Expected input 3 at 1, got %s", valueToString(pattern[in3]))
+	}
+}
+
+// createRedundantCircuit builds out = AND(AND(AND(p, q), b), NOT(p)), which is
+// constant 0, so no fault effect on f reaches out
+func createRedundantCircuit() *circuit.Circuit {
+	return buildCircuit(
+		[]string{"p", "q", "b"},
+		[]string{"out"},
+		[]testGate{
+			{"f", circuit.AND, []string{"p", "q"}},
+			{"z", circuit.NOT, []string{"p"}},
+			{"n1", circuit.AND, []string{"f", "b"}},
+			{"out", circuit.AND, []string{"n1", "z"}},
+		})
+}
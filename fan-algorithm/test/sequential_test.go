@@ -0,0 +1,467 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/atpg"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+func TestReadBench(t *testing.T) {
+	c := examples.CreateS27Circuit()
+	if len(c.PrimaryInputs) != 4 || len(c.PrimaryOutputs) != 1 || len(c.FlipFlops) != 3 {
+		t.Fatalf("Expected 4 inputs, 1 output and 3 flip-flops, got %d, %d and %d",
+			len(c.PrimaryInputs), len(c.PrimaryOutputs), len(c.FlipFlops))
+	}
+	g5, _ := c.GetFlipFlopByID("G5")
+	g10, _ := c.GetSignalByID("G10")
+	if g5.D != g10 || c.PseudoPrimaryInputs()[0] != g5.Q || c.PseudoPrimaryOutputs()[0] != g10 {
+		t.Errorf("Expected G5 to capture G10 and drive the first pseudo-primary input")
+	}
+	if len(c.ClockDomains()) != 1 || g5.Q.FanIn != nil {
+		t.Errorf("Expected flip-flop outputs without driving gates in a single clock domain")
+	}
+
+	// XOR and NAND are built from AND, OR and NOT
+	x, err := circuit.ReadBench(strings.NewReader("INPUT(a)\nINPUT(b)\nOUTPUT(y)\nOUTPUT(z)\ny = XOR(a, b)\nz = NAND(a, y)\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, _ := x.GetSignalByID("y")
+	z, _ := x.GetSignalByID("z")
+	for _, values := range [][4]circuit.SignalValue{{0, 0, 0, 1}, {0, 1, 1, 1}, {1, 0, 1, 0}, {1, 1, 0, 1}} {
+		x.PrimaryInputs[0].SetValue(values[0])
+		x.PrimaryInputs[1].SetValue(values[1])
+		x.Simulate()
+		if y.GetValue() != values[2] || z.GetValue() != values[3] {
+			t.Errorf("a=%d b=%d: expected y=%d z=%d, got %d and %d",
+				values[0], values[1], values[2], values[3], y.GetValue(), z.GetValue())
+		}
+	}
+
+	if _, err := circuit.ReadBench(strings.NewReader("INPUT(a)\nOUTPUT(y)\ny = AND(a, b)\n")); err == nil {
+		t.Error("Expected an error for the undriven signal b")
+	}
+	for _, duplicate := range []string{
+		"INPUT(a)\nINPUT(a)\nOUTPUT(y)\ny = NOT(a)\n",
+		"INPUT(a)\nOUTPUT(y)\nOUTPUT(y)\ny = NOT(a)\n",
+		"INPUT(a)\nOUTPUT(y)\ny = NOT(a)\ny = AND(a, a)\n",
+	} {
+		if _, err := circuit.ReadBench(strings.NewReader(duplicate)); err == nil {
+			t.Errorf("Expected an error for the duplicate definition in %q", duplicate)
+		}
+	}
+
+	// Lines built for XOR never take a declared name
+	n, err := circuit.ReadBench(strings.NewReader("INPUT(a)\nINPUT(b)\nOUTPUT(y)\ny = XOR(a, b)\nOUTPUT(y_na)\ny_na = NOT(b)\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	declared, _ := n.GetSignalByID("y_na")
+	if declared.FanIn == nil || declared.FanIn.Type != circuit.NOT || declared.FanIn.Inputs[0] != n.PrimaryInputs[1] {
+		t.Error("Expected y_na to keep its declared NOT gate")
+	}
+}
+
+func TestSequentialSimulation(t *testing.T) {
+	c := examples.CreateS27Circuit()
+	state := make(map[*circuit.FlipFlop]circuit.SignalValue)
+	for _, ff := range c.FlipFlops {
+		state[ff] = circuit.ZERO
+	}
+	c.SetState(state)
+
+	// Inputs G0-G3, expected G17 and the next state of G5, G6, G7
+	cycles := []struct {
+		inputs [4]circuit.SignalValue
+		output circuit.SignalValue
+		next   [3]circuit.SignalValue
+	}{
+		{[4]circuit.SignalValue{0, 1, 0, 1}, circuit.ONE, [3]circuit.SignalValue{0, 0, 1}},
+		{[4]circuit.SignalValue{1, 0, 1, 0}, circuit.ONE, [3]circuit.SignalValue{1, 0, 0}},
+		{[4]circuit.SignalValue{1, 1, 1, 1}, circuit.ONE, [3]circuit.SignalValue{1, 0, 0}},
+		{[4]circuit.SignalValue{0, 0, 0, 0}, circuit.ONE, [3]circuit.SignalValue{0, 0, 0}},
+		{[4]circuit.SignalValue{1, 0, 0, 1}, circuit.ZERO, [3]circuit.SignalValue{0, 1, 0}},
+	}
+	g17, _ := c.GetSignalByID("G17")
+	for i, cycle := range cycles {
+		inputs := make(map[*circuit.Signal]circuit.SignalValue)
+		for j, input := range c.PrimaryInputs {
+			inputs[input] = cycle.inputs[j]
+		}
+		outputs, err := c.Step(inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outputs[g17] != cycle.output {
+			t.Errorf("Cycle %d: expected G17=%d, got %d", i, cycle.output, outputs[g17])
+		}
+		for j, ff := range c.FlipFlops {
+			if ff.State != cycle.next[j] {
+				t.Errorf("Cycle %d: expected %s=%d, got %d", i, ff.ID, cycle.next[j], ff.State)
+			}
+		}
+	}
+
+	// A synchronous reset wins over the data input, a flop of another domain holds
+	g5, _ := c.GetFlipFlopByID("G5")
+	g6, _ := c.GetFlipFlopByID("G6")
+	g5.Reset, g5.ResetValue = c.PrimaryInputs[3], circuit.ONE
+	g6.Clock = "other"
+	inputs := map[*circuit.Signal]circuit.SignalValue{
+		c.PrimaryInputs[0]: circuit.ONE, c.PrimaryInputs[1]: circuit.ONE,
+		c.PrimaryInputs[2]: circuit.ONE, c.PrimaryInputs[3]: circuit.ONE,
+	}
+	before := g6.State
+	if _, err := c.Step(inputs, circuit.DefaultClock); err != nil {
+		t.Fatal(err)
+	}
+	if g5.State != circuit.ONE || g6.State != before {
+		t.Errorf("Expected G5 reset to 1 and G6 to hold, got %d and %d", g5.State, g6.State)
+	}
+}
+
+func TestFullScanATPG(t *testing.T) {
+	c := examples.CreateS27Circuit()
+	config := types.NewTestGenerationConfig()
+	config.FullScan = true
+	primary := make(map[*circuit.Signal]bool)
+	for _, signal := range c.Signals {
+		primary[signal] = signal.IsPrimary
+	}
+	driver := atpg.NewDriver(c, config)
+	report := driver.Run(atpg.AllFaults(c))
+
+	// The core is built on copies, the flip-flop lines of the circuit stay internal
+	for _, signal := range c.Signals {
+		if signal.IsPrimary != primary[signal] {
+			t.Errorf("Full-scan test generation changed whether %s is primary", signal.ID)
+		}
+	}
+
+	if report.Detected != len(report.Results) {
+		t.Errorf("Expected every fault of s27 testable under full scan, got %d of %d (%d redundant, %d aborted)",
+			report.Detected, len(report.Results), report.Redundant, report.Aborted)
+	}
+
+	fs := atpg.NewFaultSimulator(c)
+	for _, faultResult := range report.Results {
+		if faultResult.Status != atpg.DETECTED {
+			continue
+		}
+		pattern := faultResult.Result.TestPattern
+		if !fs.Detects(pattern, faultResult.Fault) {
+			t.Errorf("Pattern does not detect %s", faultResult.Fault)
+		}
+
+		// Load values come from the flip-flop outputs, unload values are captured from their data inputs
+		scan := faultResult.Scan
+		if scan == nil || len(scan.Cycles) != 1 {
+			t.Fatalf("Expected a single-capture scan pattern for %s", faultResult.Fault)
+		}
+		good := fs.Simulate(pattern, atpg.Fault{})
+		for _, ff := range c.FlipFlops {
+			if scan.Load[ff] != pattern[ff.Q] {
+				t.Errorf("%s: expected load %s=%d, got %d", faultResult.Fault, ff.ID, pattern[ff.Q], scan.Load[ff])
+			}
+			if scan.Unload[ff] != good[ff.D] {
+				t.Errorf("%s: expected unload %s=%d, got %d", faultResult.Fault, ff.ID, good[ff.D], scan.Unload[ff])
+			}
+		}
+		for _, output := range c.PrimaryOutputs {
+			if scan.Cycles[0].Outputs[output] != good[output] {
+				t.Errorf("%s: expected %s=%d in the capture cycle", faultResult.Fault, output.ID, good[output])
+			}
+		}
+	}
+
+	// Building a scan pattern leaves the state and values of the circuit as they were
+	state := c.GetState()
+	values := make(map[*circuit.Signal]circuit.SignalValue)
+	for _, signal := range c.Signals {
+		values[signal] = signal.Value
+	}
+	if _, err := atpg.NewScanPattern(c, report.Results[0].Result.TestPattern); err != nil {
+		t.Fatal(err)
+	}
+	for _, ff := range c.FlipFlops {
+		if ff.State != state[ff] {
+			t.Errorf("Scan pattern changed the state of %s", ff.ID)
+		}
+	}
+	for _, signal := range c.Signals {
+		if signal.Value != values[signal] {
+			t.Errorf("Scan pattern changed the value of %s", signal.ID)
+		}
+	}
+}
+
+func TestScanSequence(t *testing.T) {
+	c := examples.CreateS27Circuit()
+	g5, _ := c.GetFlipFlopByID("G5")
+	g6, _ := c.GetFlipFlopByID("G6")
+	g7, _ := c.GetFlipFlopByID("G7")
+	long := &circuit.ScanChain{
+		Name: "long", ScanIn: "si0", ScanOut: "so0",
+		Cells:          []circuit.ScanCell{{FlipFlop: g5, Inverted: true}, {FlipFlop: g7}},
+		OutputInverted: true,
+	}
+	if err := c.AddScanChain(long); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddScanChain(circuit.NewScanChain("reused", "si1", "so1", g7)); err == nil {
+		t.Error("Expected an error for a flip-flop on two chains")
+	}
+	if chains := c.StitchScanChains(4); len(chains) != 1 || chains[0].Cells[0].FlipFlop != g6 {
+		t.Fatalf("Expected G6 stitched onto a single new chain")
+	}
+
+	config := types.NewTestGenerationConfig()
+	config.FullScan = true
+	patterns := make([]*atpg.ScanPattern, 0)
+	for _, faultResult := range atpg.NewDriver(c, config).Run(atpg.AllFaults(c)).Results[:6] {
+		patterns = append(patterns, faultResult.Scan)
+	}
+	cycles, err := atpg.ScanSequence(c, patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two shifts per load, one capture per pattern, unloads overlap the next load
+	if len(cycles) != 2*(len(patterns)+1)+len(patterns) {
+		t.Errorf("Expected %d cycles, got %d", 2*(len(patterns)+1)+len(patterns), len(cycles))
+	}
+
+	// Apply the sequence to the circuit, every compared value must match
+	compared := 0
+	for _, cycle := range atpg.FlushSequence(c) {
+		for _, chain := range c.ScanChains {
+			if out := chain.Shift(cycle.ScanIn[chain]); cycle.ScanOut[chain] != circuit.X {
+				compared++
+				if out != cycle.ScanOut[chain] {
+					t.Errorf("Flush of chain %s: expected %d, got %d", chain.Name, cycle.ScanOut[chain], out)
+				}
+			}
+		}
+	}
+	for i, cycle := range cycles {
+		if cycle.Shift {
+			for _, chain := range c.ScanChains {
+				if out := chain.Shift(cycle.ScanIn[chain]); cycle.ScanOut[chain] != circuit.X {
+					compared++
+					if out != cycle.ScanOut[chain] {
+						t.Errorf("Cycle %d: expected %d at %s, got %d", i, cycle.ScanOut[chain], chain.ScanOut, out)
+					}
+				}
+			}
+			continue
+		}
+
+		outputs, err := c.Step(cycle.Capture.Inputs, cycle.Capture.Domains...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for output, expected := range cycle.Capture.Outputs {
+			if expected != circuit.X && outputs[output] != expected {
+				t.Errorf("Cycle %d: expected %s=%d, got %d", i, output.ID, expected, outputs[output])
+			}
+		}
+	}
+	if compared == 0 {
+		t.Error("Expected scan outputs to compare")
+	}
+
+	var text strings.Builder
+	if err := atpg.WriteScanSequence(&text, c, cycles); err != nil || !strings.Contains(text.String(), "capture ") {
+		t.Errorf("Expected capture lines in the formatted sequence")
+	}
+}
+
+func TestTimeFrameExpansion(t *testing.T) {
+	c := examples.CreateS27Circuit()
+	config := types.NewTestGenerationConfig()
+	config.MaxTimeFrames = 1
+	single := atpg.NewDriver(c, config).Run(atpg.AllFaults(c))
+	config.MaxTimeFrames = 3
+	report := atpg.NewDriver(c, config).Run(atpg.AllFaults(c))
+
+	if report.Detected <= single.Detected || report.Detected < 40 {
+		t.Errorf("Expected more frames to detect more faults, got %d with one frame and %d with three",
+			single.Detected, report.Detected)
+	}
+	initialized := 0
+	for _, faultResult := range report.Results {
+		if faultResult.Status != atpg.DETECTED {
+			if faultResult.Result.Error != types.ErrFrameLimit {
+				t.Errorf("Expected %s to hit the frame limit", faultResult.Fault)
+			}
+			continue
+		}
+		test := faultResult.Sequence
+		if len(test.Initialization()) > 0 {
+			initialized++
+		}
+		if !detectsSequentially(faultResult.Fault, test.Sequence) {
+			t.Errorf("Sequence for %s does not detect it from the unknown state", faultResult.Fault)
+		}
+	}
+	if initialized == 0 {
+		t.Error("Expected faults needing an initialization sequence")
+	}
+}
+
+func TestTimeFrameConflictClauses(t *testing.T) {
+	// n0 reaches q0, so the time-frame expansion of n0/sa1 carries the fault
+	// effect into later frames on lines no input controls. A learned clause
+	// only holds for the fault-free circuit if it conflicts with those lines
+	// at their good value, otherwise FAN prunes the frames holding the test.
+	c, err := circuit.ReadBench(strings.NewReader(`INPUT(i0)
+INPUT(i1)
+INPUT(i2)
+OUTPUT(n8)
+n0 = XOR(q0, i1)
+n1 = NOR(i2, n0)
+n3 = OR(i1, i0)
+n6 = NOR(n1, i0)
+n7 = NAND(n6, n3)
+n8 = NOR(n7, q1)
+q0 = DFF(n1)
+q1 = DFF(n7)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n0, _ := c.GetSignalByID("n0")
+	fault := atpg.Fault{Site: n0, StuckAt: circuit.ONE}
+	faultResult := atpg.NewDriver(c, types.NewTestGenerationConfig()).RunFault(fault)
+	if faultResult.Status != atpg.DETECTED {
+		t.Fatalf("Expected a test sequence for %s, got status %d: %v", fault, faultResult.Status, faultResult.Result.Error)
+	}
+}
+
+// detectsSequentially applies the sequence to s27 and to a copy with the
+// fault, both starting from the unknown state, and compares their outputs
+func detectsSequentially(fault atpg.Fault, sequence []map[*circuit.Signal]circuit.SignalValue) bool {
+	good, faulty := examples.CreateS27Circuit(), examples.CreateS27Circuit()
+
+	// The faulty line loses its driver and keeps the stuck-at value
+	site, _ := faulty.GetSignalByID(fault.Site.ID)
+	for i, gate := range faulty.Gates {
+		if gate == site.FanIn {
+			faulty.Gates = append(faulty.Gates[:i], faulty.Gates[i+1:]...)
+			break
+		}
+	}
+	site.FanIn = nil
+	for i, input := range faulty.PrimaryInputs {
+		if input == site {
+			faulty.PrimaryInputs = append(faulty.PrimaryInputs[:i], faulty.PrimaryInputs[i+1:]...)
+			break
+		}
+	}
+	for _, ff := range faulty.FlipFlops {
+		if ff.Q == site {
+			ff.Q = circuit.NewSignal(ff.ID + "_cut")
+		}
+	}
+	site.SetValue(fault.StuckAt)
+
+	for _, inputs := range sequence {
+		goodInputs := make(map[*circuit.Signal]circuit.SignalValue)
+		faultyInputs := make(map[*circuit.Signal]circuit.SignalValue)
+		for input, value := range inputs {
+			goodInput, _ := good.GetSignalByID(input.ID)
+			faultyInput, _ := faulty.GetSignalByID(input.ID)
+			goodInputs[goodInput], faultyInputs[faultyInput] = value, value
+		}
+		goodOutputs, _ := good.Step(goodInputs)
+		faultyOutputs, _ := faulty.Step(faultyInputs)
+		for i, output := range good.PrimaryOutputs {
+			g, f := goodOutputs[output], faultyOutputs[faulty.PrimaryOutputs[i]]
+			if g != f && g != circuit.X && f != circuit.X {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestTransitionFaults(t *testing.T) {
+	for _, mode := range []types.LaunchMode{types.LAUNCH_ON_CAPTURE, types.LAUNCH_ON_SHIFT} {
+		c := examples.CreateS27Circuit()
+		c.StitchScanChains(1)
+		config := types.NewTestGenerationConfig()
+		config.LaunchMode = mode
+		report, err := atpg.NewDriver(c, config).RunTransitionFaults(atpg.AllTransitionFaults(c))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fs := atpg.NewFaultSimulator(c)
+		patterns := make([]*atpg.ScanPattern, 0)
+		for _, result := range report.Results {
+			if result.Status != atpg.DETECTED {
+				continue
+			}
+			initialization, launch := result.Scan.TransitionVectors(c)
+			if !fs.DetectsTransition(initialization, launch, result.Fault) {
+				t.Errorf("Mode %d: test for %s does not detect it", mode, result.Fault)
+			}
+			patterns = append(patterns, result.Scan)
+		}
+
+		// Launch-on-capture reaches every fault of s27, launch-on-shift holds
+		// the primary inputs so their transitions are untestable
+		g0, _ := c.GetSignalByID("G0")
+		switch {
+		case mode == types.LAUNCH_ON_CAPTURE && report.Detected != len(report.Results):
+			t.Errorf("Expected every transition fault detected with launch-on-capture, got %d of %d",
+				report.Detected, len(report.Results))
+		case mode == types.LAUNCH_ON_SHIFT && (report.Results[0].Fault.Site != g0 || report.Results[0].Status != atpg.REDUNDANT):
+			t.Errorf("Expected G0/str untestable with launch-on-shift")
+		}
+		if mode == types.LAUNCH_ON_CAPTURE {
+			continue
+		}
+
+		// The launching shift follows the load and precedes the single capture
+		cycles, err := atpg.ScanSequence(c, patterns[:1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cycles) != 2*len(c.FlipFlops)+2 || !cycles[len(c.FlipFlops)].Shift || cycles[len(c.FlipFlops)+1].Shift {
+			t.Fatalf("Expected load, launch shift, capture and unload, got %d cycles", len(cycles))
+		}
+		for i, cycle := range cycles {
+			if cycle.Shift {
+				out := c.ScanChains[0].Shift(cycle.ScanIn[c.ScanChains[0]])
+				if expected := cycle.ScanOut[c.ScanChains[0]]; expected != circuit.X && out != expected {
+					t.Errorf("Cycle %d: expected scan output %d, got %d", i, expected, out)
+				}
+				continue
+			}
+			outputs, _ := c.Step(cycle.Capture.Inputs, cycle.Capture.Domains...)
+			for output, expected := range cycle.Capture.Outputs {
+				if expected != circuit.X && outputs[output] != expected {
+					t.Errorf("Cycle %d: expected %s=%d, got %d", i, output.ID, expected, outputs[output])
+				}
+			}
+		}
+	}
+}
+
+func TestTransitionFaultsNeedScanChains(t *testing.T) {
+	c := examples.CreateS27Circuit()
+	config := types.NewTestGenerationConfig()
+	config.FullScan = true
+	if _, err := atpg.NewDriver(c, config).RunTransitionFaults(atpg.AllTransitionFaults(c)); err == nil {
+		t.Error("Expected an error for full scan without scan chains")
+	}
+
+	c.StitchScanChains(1)
+	if _, err := atpg.NewDriver(c, config).RunTransitionFaults(atpg.AllTransitionFaults(c)); err != nil {
+		t.Errorf("Expected full scan to run once every flip-flop is on a chain: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/examples"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/atpg"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/internal/circuit"
+	"github.com/fyerfyer/FAN-algorithm/fan-algorithm/pkg/types"
+)
+
+func TestCOP(t *testing.T) {
+	c := examples.CreateReconvergentCircuit()
+	cop := c.ComputeCOP()
+
+	// id: probability of 1, observability
+	expected := map[string][2]float64{
+		"x":   {0.25, 0.375},
+		"z":   {0.4375, 0.5},
+		"d":   {0.5, 0.4375},
+		"out": {0.21875, 1},
+		"a":   {0.5, 1 - 0.8125*0.8125}, // Observed through x or y
+	}
+	for id, measures := range expected {
+		signal, _ := c.GetSignalByID(id)
+		if math.Abs(cop.Probability[signal]-measures[0]) > 1e-9 ||
+			math.Abs(cop.Observability[signal]-measures[1]) > 1e-9 {
+			t.Errorf("Signal %s: expected %v, got %v, %v", id, measures,
+				cop.Probability[signal], cop.Observability[signal])
+		}
+	}
+
+	z, _ := c.GetSignalByID("z")
+	if p := cop.DetectionProbability(z, circuit.ONE); math.Abs(p-0.5625*0.5) > 1e-9 {
+		t.Errorf("Expected detection probability 0.28125 for z/sa1, got %f", p)
+	}
+}
+
+func TestRandomPatternPhase(t *testing.T) {
+	// The output of an 8-input AND is 1 for one pattern in 256
+	inputs := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	c := buildCircuit(inputs, []string{"out", "n"}, []testGate{
+		{"out", circuit.AND, inputs},
+		{"n", circuit.NOT, []string{"a"}},
+	})
+	out, _ := c.GetSignalByID("out")
+
+	config := types.NewTestGenerationConfig()
+	config.RandomPatterns = 64
+	report := atpg.NewDriver(c, config).Run(atpg.AllFaults(c))
+
+	if report.Detected != len(report.Results) {
+		t.Fatalf("Expected all faults detected, got %d of %d", report.Detected, len(report.Results))
+	}
+	if report.RandomRuns == 0 || report.RandomSkipped == 0 {
+		t.Errorf("Expected faults detected by random patterns and resistant faults skipped, got %d and %d",
+			report.RandomRuns, report.RandomSkipped)
+	}
+
+	fs := atpg.NewFaultSimulator(c)
+	for _, faultResult := range report.Results {
+		if !fs.Detects(faultResult.Result.TestPattern, faultResult.Fault) {
+			t.Errorf("Pattern from %s does not detect %s", faultResult.Engine, faultResult.Fault)
+		}
+		if len(faultResult.Result.TestPattern) != len(inputs) {
+			t.Errorf("Pattern from %s holds %d values, expected the %d inputs",
+				faultResult.Engine, len(faultResult.Result.TestPattern), len(inputs))
+		}
+		// out/sa0 needs all inputs at 1, it is predicted resistant and left to FAN
+		if faultResult.Fault.Site == out && faultResult.Fault.StuckAt == circuit.ZERO &&
+			faultResult.Engine == atpg.RandomEngine {
+			t.Errorf("Expected out/sa0 to skip random simulation")
+		}
+	}
+}
+
+func TestTestPointInsertion(t *testing.T) {
+	// p = AND(OR(AND(i0..i7), i8), i9), the wide AND is random-pattern resistant
+	wide := []string{"i0", "i1", "i2", "i3", "i4", "i5", "i6", "i7"}
+	create := func() *circuit.Circuit {
+		return buildCircuit(append(append([]string{}, wide...), "i8", "i9"), []string{"p"}, []testGate{
+			{"w", circuit.AND, wide},
+			{"o", circuit.OR, []string{"w", "i8"}},
+			{"p", circuit.AND, []string{"o", "i9"}},
+		})
+	}
+	c, reference := create(), create()
+	faults := atpg.AllFaults(c)
+	before := len(atpg.NewFaultSimulator(c).RandomPatterns(faults, 64, 1))
+
+	points := c.RecommendTestPoints(1.0/64, 4)
+	if len(points) == 0 {
+		t.Fatal("Expected test points for the wide AND")
+	}
+	w, _ := c.GetSignalByID("w")
+	if points[0].Signal != w || points[0].Kind != circuit.OBSERVATION_POINT {
+		t.Errorf("Expected an observation point on w first, got %s kind %d", points[0].Signal.ID, points[0].Kind)
+	}
+
+	added := c.InsertTestPoints(points)
+	if len(added) != len(points) || len(c.PrimaryInputs)+len(c.PrimaryOutputs) != 11+len(points) {
+		t.Errorf("Expected one new primary input or output per test point")
+	}
+	after := len(atpg.NewFaultSimulator(c).RandomPatterns(faults, 64, 1))
+	if after <= before {
+		t.Errorf("Expected test points to raise random coverage, got %d before and %d after", before, after)
+	}
+
+	// With the control inputs at their non-controlling values the function is unchanged
+	p, _ := c.GetSignalByID("p")
+	referenceP, _ := reference.GetSignalByID("p")
+	for _, fill := range []func(int) circuit.SignalValue{
+		func(int) circuit.SignalValue { return circuit.ONE },
+		func(i int) circuit.SignalValue { return circuit.SignalValue(i % 2) },
+		func(i int) circuit.SignalValue { return circuit.SignalValue(1 - i%2) },
+	} {
+		pattern := make(map[*circuit.Signal]circuit.SignalValue)
+		referencePattern := make(map[*circuit.Signal]circuit.SignalValue)
+		for i, input := range reference.PrimaryInputs {
+			referencePattern[input] = fill(i)
+			pattern[c.PrimaryInputs[i]] = fill(i)
+		}
+		for i, point := range points {
+			switch point.Kind {
+			case circuit.CONTROL_0_POINT:
+				pattern[added[i]] = circuit.ONE
+			case circuit.CONTROL_1_POINT:
+				pattern[added[i]] = circuit.ZERO
+			}
+		}
+
+		got := atpg.NewFaultSimulator(c).Simulate(pattern, atpg.Fault{})[p]
+		expected := atpg.NewFaultSimulator(reference).Simulate(referencePattern, atpg.Fault{})[referenceP]
+		if got != expected {
+			t.Errorf("Test points changed the circuit function: p=%d, expected %d", got, expected)
+		}
+	}
+}